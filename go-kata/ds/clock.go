@@ -0,0 +1,194 @@
+package ds
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the Scheduler and anything built on it, so
+// tests can swap in a FakeClock instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer mirrors the parts of time.Timer that callers use. C is nil for
+// timers created by AfterFunc.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type RealClock struct{}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (RealClock) Now() time.Time { return time.Now() }
+
+func (RealClock) NewTimer(d time.Duration) Timer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{t: time.AfterFunc(d, f)}
+}
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+// FakeClock only moves when told to. Advance and Set fire every timer that
+// has come due, in deadline order; AfterFunc callbacks run synchronously on
+// the advancing goroutine.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	c    *FakeClock
+	when time.Time
+	ch   chan time.Time
+	fn   func()
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{c: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d. Concurrent calls add up.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.move(c.now.Add(d))
+}
+
+// Set moves the clock to now, which may not be earlier than the current time.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.move(now)
+}
+
+// move is Set with c.mu already held; it releases the lock before firing
+// timers.
+func (c *FakeClock) move(now time.Time) {
+	if now.Before(c.now) {
+		c.mu.Unlock()
+		panic("FakeClock cannot go backwards!!")
+	}
+	c.now = now
+
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].when.Before(c.timers[j].when) })
+
+	var fired []*fakeTimer
+	for len(c.timers) > 0 && !c.timers[0].when.After(now) {
+		fired = append(fired, c.timers[0])
+		c.timers = c.timers[1:]
+	}
+	c.cond.Broadcast()
+	c.mu.Unlock()
+
+	for _, t := range fired {
+		if t.fn != nil {
+			t.fn()
+			continue
+		}
+		select {
+		case t.ch <- now:
+		default:
+		}
+	}
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits until at least n timers are waiting to fire, so a test can
+// be sure a goroutine has armed its timer before advancing the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// remove drops t from the waiting timers. Callers hold c.mu.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	t.drain()
+	return t.c.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	active := t.c.remove(t)
+	t.drain()
+	t.when = t.c.now.Add(d)
+	t.c.timers = append(t.c.timers, t)
+	t.c.cond.Broadcast()
+	t.c.mu.Unlock()
+
+	// A timer armed in the past fires straight away, like time.Timer
+	if d <= 0 {
+		t.c.Set(t.c.Now())
+	}
+
+	return active
+}
+
+// drain discards a value that was sent but not received, matching the Go 1.23
+// time.Timer guarantee that no stale value arrives after Stop or Reset.
+func (t *fakeTimer) drain() {
+	if t.ch == nil {
+		return
+	}
+	select {
+	case <-t.ch:
+	default:
+	}
+}
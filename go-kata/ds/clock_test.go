@@ -0,0 +1,149 @@
+package ds
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFakeClockAdvance(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	if !c.Now().Equal(epoch) {
+		t.Errorf("Expected %v, got %v", epoch, c.Now())
+	}
+
+	c.Advance(time.Minute)
+	if !c.Now().Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Expected %v, got %v", epoch.Add(time.Minute), c.Now())
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	c := NewFakeClock(epoch)
+	timer := c.NewTimer(10 * time.Second)
+
+	c.Advance(9 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("Timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case fired := <-timer.C():
+		if !fired.Equal(epoch.Add(10 * time.Second)) {
+			t.Errorf("Expected fire time %v, got %v", epoch.Add(10*time.Second), fired)
+		}
+	default:
+		t.Fatal("Timer did not fire")
+	}
+
+	if c.Timers() != 0 {
+		t.Errorf("Expected no waiting timers, got %d", c.Timers())
+	}
+}
+
+func TestFakeClockStopAndReset(t *testing.T) {
+	c := NewFakeClock(epoch)
+	timer := c.NewTimer(time.Second)
+
+	if !timer.Stop() {
+		t.Error("Expected Stop to report an active timer")
+	}
+	if timer.Stop() {
+		t.Error("Expected second Stop to report an inactive timer")
+	}
+
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("Stopped timer fired")
+	default:
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Expected Reset of a stopped timer to report inactive")
+	}
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("Reset timer did not fire")
+	}
+}
+
+func TestFakeClockAfterFunc(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	var order []int
+	c.AfterFunc(2*time.Second, func() { order = append(order, 2) })
+	c.AfterFunc(time.Second, func() { order = append(order, 1) })
+	stopped := c.AfterFunc(time.Second, func() { order = append(order, -1) })
+	stopped.Stop()
+
+	c.Advance(5 * time.Second)
+
+	if len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("Expected callbacks [1 2], got %v", order)
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	armed := make(chan Timer)
+	go func() { armed <- c.NewTimer(time.Second) }()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+
+	timer := <-armed
+	select {
+	case <-timer.C():
+	default:
+		t.Fatal("Timer did not fire")
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	var c Clock = RealClock{}
+
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		t.Fatal("Real timer did not fire")
+	}
+
+	done := make(chan struct{})
+	c.AfterFunc(time.Millisecond, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Real AfterFunc did not run")
+	}
+}
+
+func TestFakeClockConcurrentAdvance(t *testing.T) {
+	c := NewFakeClock(epoch)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Advance(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := c.Now().Sub(epoch); got != 8*time.Second {
+		t.Errorf("Expected the clock 8s on, got %v", got)
+	}
+}
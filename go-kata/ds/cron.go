@@ -0,0 +1,252 @@
+package ds
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression. It accepts the standard 5-field form
+// (minute hour day-of-month month day-of-week) and a 6-field form with a
+// leading seconds field. Fields take *, ?, lists, ranges and steps (1,5 2-4
+// */15 10-50/5 3/10); months and weekdays also take names (JAN, MON), and
+// weekday 7 is Sunday. @yearly, @monthly, @weekly, @daily and @hourly are
+// accepted as shorthands.
+//
+// As in Vixie cron, when both day-of-month and day-of-week are restricted a
+// day matches if either does.
+type Cron struct {
+	second uint64
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	domStar bool
+	dowStar bool
+}
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	secondField = cronField{min: 0, max: 59}
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+var cronShorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+func ParseCron(expr string) (*Cron, error) {
+
+	if full, ok := cronShorthands[strings.TrimSpace(expr)]; ok {
+		expr = full
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("Cron expression %q must have 5 or 6 fields!!", expr)
+	}
+
+	c := &Cron{
+		domStar: isStar(fields[3]),
+		dowStar: isStar(fields[5]),
+	}
+
+	targets := []*uint64{&c.second, &c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	specs := []cronField{secondField, minuteField, hourField, domField, monthField, dowField}
+
+	for i, field := range fields {
+		bits, err := specs[i].parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("Cron expression %q: %w", expr, err)
+		}
+		*targets[i] = bits
+	}
+
+	// Sunday may be written as 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	return c, nil
+}
+
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := f.min, f.max, 1
+
+		rng := part
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			s, err := strconv.Atoi(part[slash+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = s
+			rng = part[:slash]
+		}
+
+		switch {
+		case isStar(rng):
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "3/10" runs from 3 to the end of the range; a bare "3" is just 3
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		if lo > hi {
+			return 0, fmt.Errorf("empty range in %q", part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", v, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first matching time strictly after after, in after's
+// location, or the zero time if nothing matches within five years (e.g.
+// "0 0 30 2 *").
+func (c *Cron) Next(after time.Time) time.Time {
+
+	loc := after.Location()
+	t := after.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+
+	// Each field is advanced in turn, largest first. The first time a field
+	// moves, the smaller units are zeroed (tracked by added). When a field
+	// wraps into the next larger unit, start over so that unit is re-checked.
+	added := false
+
+WRAP:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+
+	for c.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !c.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for c.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for c.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for c.second&(1<<uint(t.Second())) == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,84 @@
+package ds
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatalf("bad time %q: %v", s, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		after    string
+		expected string
+	}{
+		{name: "every minute", expr: "* * * * *", after: "2024-03-10 10:30:45", expected: "2024-03-10 10:31:00"},
+		{name: "top of the hour", expr: "0 * * * *", after: "2024-03-10 10:30:00", expected: "2024-03-10 11:00:00"},
+		{name: "strictly after", expr: "0 * * * *", after: "2024-03-10 11:00:00", expected: "2024-03-10 12:00:00"},
+		{name: "step minutes", expr: "*/15 * * * *", after: "2024-03-10 10:31:00", expected: "2024-03-10 10:45:00"},
+		{name: "range with step", expr: "0 9-17/4 * * *", after: "2024-03-10 13:00:00", expected: "2024-03-10 17:00:00"},
+		{name: "list of hours wraps day", expr: "30 6,18 * * *", after: "2024-03-10 19:00:00", expected: "2024-03-11 06:30:00"},
+		{name: "weekday names", expr: "0 9 * * MON-FRI", after: "2024-03-09 12:00:00", expected: "2024-03-11 09:00:00"},
+		{name: "sunday as 7", expr: "0 0 * * 7", after: "2024-03-10 00:00:00", expected: "2024-03-17 00:00:00"},
+		{name: "month names wrap year", expr: "0 0 1 JAN *", after: "2024-03-10 00:00:00", expected: "2025-01-01 00:00:00"},
+		{name: "leap day", expr: "0 12 29 2 *", after: "2024-03-01 00:00:00", expected: "2028-02-29 12:00:00"},
+		{name: "dom or dow", expr: "0 0 13 * FRI", after: "2024-03-10 00:00:00", expected: "2024-03-13 00:00:00"},
+		{name: "seconds field", expr: "*/10 * * * * *", after: "2024-03-10 10:30:45", expected: "2024-03-10 10:30:50"},
+		{name: "step from value", expr: "0 5/20 * * * *", after: "2024-03-10 10:30:00", expected: "2024-03-10 10:45:00"},
+		{name: "shorthand", expr: "@daily", after: "2024-03-10 10:30:00", expected: "2024-03-11 00:00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) returned error: %v", tt.expr, err)
+			}
+
+			got := c.Next(at(tt.after))
+			if !got.Equal(at(tt.expected)) {
+				t.Errorf("ParseCron(%q).Next(%s) = %v, want %s", tt.expr, tt.after, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCronNextImpossible(t *testing.T) {
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := c.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Expected zero time for February 30th, got %v", got)
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"* * * FOO *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+}
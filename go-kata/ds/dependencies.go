@@ -0,0 +1,175 @@
+package ds
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddDependency makes ID wait for prereq: ID is held back from PopDue until
+// Complete(prereq) is called. Either task may be added before or after the
+// edge. An edge that would close a cycle is rejected, and a prerequisite
+// that has already completed is ignored.
+func (s *Scheduler) AddDependency(ID, prereq string) error {
+
+	if ID == prereq {
+		return fmt.Errorf("Task %q cannot depend on itself!!", ID)
+	}
+
+	if _, done := s.completed[prereq]; done {
+		return nil
+	}
+
+	if s.dependsOn(prereq, ID) {
+		return fmt.Errorf("Dependency %q -> %q would create a cycle!!", ID, prereq)
+	}
+
+	addEdge(s.waitingOn, ID, prereq)
+	addEdge(s.waiters, prereq, ID)
+
+	// Take a task that was ready off the heap until prereq completes
+	s.ready.Delete(ID)
+
+	return nil
+}
+
+// dependsOn reports whether from waits on to, directly or transitively.
+func (s *Scheduler) dependsOn(from, to string) bool {
+
+	seen := map[string]bool{from: true}
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for prereq := range s.waitingOn[id] {
+			if prereq == to {
+				return true
+			}
+			if !seen[prereq] {
+				seen[prereq] = true
+				stack = append(stack, prereq)
+			}
+		}
+	}
+
+	return false
+}
+
+func addEdge(edges map[string]map[string]struct{}, from, to string) {
+	if edges[from] == nil {
+		edges[from] = make(map[string]struct{})
+	}
+	edges[from][to] = struct{}{}
+}
+
+func removeEdge(edges map[string]map[string]struct{}, from, to string) {
+	delete(edges[from], to)
+	if len(edges[from]) == 0 {
+		delete(edges, from)
+	}
+}
+
+func (s *Scheduler) blocked(ID string) bool {
+	return len(s.waitingOn[ID]) > 0
+}
+
+// dropPrerequisites forgets what ID was waiting on. Tasks waiting on ID keep
+// waiting until it is completed.
+func (s *Scheduler) dropPrerequisites(ID string) {
+	for prereq := range s.waitingOn[ID] {
+		removeEdge(s.waiters, prereq, ID)
+	}
+	delete(s.waitingOn, ID)
+}
+
+// DefaultCompletionRetention is how many completions a Scheduler remembers
+// for dependencies added after their prerequisite finished.
+const DefaultCompletionRetention = 4096
+
+type completion struct {
+	ID string
+	n  uint64
+}
+
+// SetCompletionRetention keeps only the last n completions. An ID whose
+// completion is forgotten counts as unfinished again, so a dependency added
+// on it later waits for it to complete again. n <= 0 remembers none.
+func (s *Scheduler) SetCompletionRetention(n int) {
+	s.retention = n
+	s.forgetCompletions()
+}
+
+func (s *Scheduler) forgetCompletions() {
+	for len(s.doneLog) > max(s.retention, 0) {
+		c := s.doneLog[0]
+		s.doneLog = s.doneLog[1:]
+		// A later Complete or AddOrUpdate of the same ID supersedes c
+		if n, ok := s.completed[c.ID]; ok && n == c.n {
+			delete(s.completed, c.ID)
+		}
+	}
+}
+
+// Complete marks ID as finished and releases every task that was only
+// waiting on it. Later dependencies on ID are satisfied straight away, until
+// ID is scheduled again or falls out of the completion retention.
+//
+// Completing a leased or in-flight task also acknowledges it, like Ack. A
+// task that is still queued and was not handed out has not run, so
+// completing it is refused and Complete returns false, unless it is
+// recurring and what is queued is its next occurrence.
+func (s *Scheduler) Complete(ID string) bool {
+
+	_, handedOut := s.takeInFlight(ID)
+	if t, queued := s.byID[ID]; queued && t.recur == nil && !handedOut {
+		return false
+	}
+
+	s.doneCount++
+	s.completed[ID] = s.doneCount
+	s.doneLog = append(s.doneLog, completion{ID: ID, n: s.doneCount})
+	s.forgetCompletions()
+
+	for waiter := range s.waiters[ID] {
+		removeEdge(s.waitingOn, waiter, ID)
+
+		if t, ok := s.byID[waiter]; ok && !s.blocked(waiter) {
+			s.ready.Set(waiter, t)
+		}
+	}
+	delete(s.waiters, ID)
+
+	return true
+}
+
+// ReadySet returns the IDs of queued tasks with no unfinished prerequisites,
+// in the order PopDue would hand them out.
+func (s *Scheduler) ReadySet() []string {
+
+	ids := make([]string, 0, s.ready.Len())
+	for id := range s.ready.All() {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// Blocked returns each queued task that is held back, with the sorted IDs of
+// the prerequisites it is still waiting on.
+func (s *Scheduler) Blocked() map[string][]string {
+
+	blocked := make(map[string][]string)
+
+	for id, prereqs := range s.waitingOn {
+		if _, ok := s.byID[id]; !ok {
+			continue
+		}
+		for prereq := range prereqs {
+			blocked[id] = append(blocked[id], prereq)
+		}
+		sort.Strings(blocked[id])
+	}
+
+	return blocked
+}
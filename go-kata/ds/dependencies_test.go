@@ -0,0 +1,233 @@
+package ds
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDependencyHoldsTaskBack(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("build", now.Add(2*time.Second))
+	s.AddOrUpdate("test", now)
+	s.AddOrUpdate("deploy", now.Add(-time.Second))
+
+	if err := s.AddDependency("test", "build"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddDependency("deploy", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.ReadySet(); !slices.Equal(got, []string{"build"}) {
+		t.Errorf("Expected ready set [build], got %v", got)
+	}
+
+	if due := s.PopDue(now.Add(time.Hour)); len(due) != 1 || due[0].ID != "build" {
+		t.Fatalf("Expected only build to be due, got %v", ids(due))
+	}
+
+	s.Complete("build")
+
+	if due := s.PopDue(now.Add(time.Hour)); len(due) != 1 || due[0].ID != "test" {
+		t.Fatalf("Expected test after build completed, got %v", ids(due))
+	}
+
+	s.Complete("test")
+
+	if due := s.PopDue(now.Add(time.Hour)); len(due) != 1 || due[0].ID != "deploy" {
+		t.Fatalf("Expected deploy after test completed, got %v", ids(due))
+	}
+}
+
+func TestDependencyWaitsForAllPrerequisites(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("report", now)
+	s.AddDependency("report", "fetch-a")
+	s.AddDependency("report", "fetch-b")
+
+	blocked := s.Blocked()
+	if got := blocked["report"]; !slices.Equal(got, []string{"fetch-a", "fetch-b"}) {
+		t.Errorf("Expected report blocked on [fetch-a fetch-b], got %v", got)
+	}
+
+	s.Complete("fetch-a")
+	if len(s.PopDue(now)) != 0 {
+		t.Error("Expected report to wait for fetch-b")
+	}
+
+	s.Complete("fetch-b")
+	if due := s.PopDue(now); len(due) != 1 || due[0].ID != "report" {
+		t.Errorf("Expected report to be released, got %v", ids(due))
+	}
+	if len(s.Blocked()) != 0 {
+		t.Errorf("Expected nothing blocked, got %v", s.Blocked())
+	}
+}
+
+func TestDependencyRejectsCycles(t *testing.T) {
+	s := CreateScheduler()
+
+	if err := s.AddDependency("a", "a"); err == nil {
+		t.Error("Expected a self dependency to fail")
+	}
+
+	s.AddDependency("b", "a")
+	s.AddDependency("c", "b")
+
+	if err := s.AddDependency("a", "c"); err == nil {
+		t.Error("Expected a -> c to be rejected as a cycle")
+	}
+	if err := s.AddDependency("c", "a"); err != nil {
+		t.Errorf("Expected a redundant edge to be accepted, got %v", err)
+	}
+}
+
+func TestDependencyBeforeTaskIsAdded(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddDependency("child", "parent")
+	s.AddOrUpdate("child", now)
+
+	if due := s.PopDue(now); len(due) != 0 {
+		t.Fatalf("Expected child to be held back, got %v", ids(due))
+	}
+
+	// Updating a blocked task must not touch the heap
+	s.AddOrUpdate("child", now.Add(-time.Second), WithPriority(3))
+
+	s.Complete("parent")
+	due := s.PopDue(now)
+	if len(due) != 1 || due[0].ID != "child" || due[0].Priority() != 3 {
+		t.Errorf("Expected the updated child, got %v", ids(due))
+	}
+}
+
+func TestDependencyOnCompletedTask(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.Complete("setup")
+	s.AddOrUpdate("job", now)
+	s.AddDependency("job", "setup")
+
+	if due := s.PopDue(now); len(due) != 1 {
+		t.Errorf("Expected a completed prerequisite to be satisfied, got %v", ids(due))
+	}
+
+	// Scheduling setup again means later dependents wait for it
+	s.AddOrUpdate("setup", now.Add(time.Hour))
+	s.AddOrUpdate("job2", now)
+	s.AddDependency("job2", "setup")
+
+	if due := s.PopDue(now); len(due) != 0 {
+		t.Errorf("Expected job2 to wait for the new setup run, got %v", ids(due))
+	}
+}
+
+func TestRemoveBlockedTask(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("a", now)
+	s.AddOrUpdate("b", now)
+	s.AddDependency("b", "a")
+
+	if !s.Remove("b") {
+		t.Fatal("Expected the blocked task to be removed")
+	}
+	if len(s.Blocked()) != 0 || len(s.waiters) != 0 {
+		t.Errorf("Expected b's edges to be dropped, got %v", s.waiters)
+	}
+
+	s.Complete("a")
+	if due := s.PopDue(now); len(due) != 1 || due[0].ID != "a" {
+		t.Errorf("Expected only a, got %v", ids(due))
+	}
+}
+
+func TestReadySetMatchesPopDueOnTies(t *testing.T) {
+	s := CreateSchedulerWithClock(NewFakeClock(epoch))
+
+	for i := 0; i < 12; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%02d", i), epoch)
+	}
+	// A released task keeps its place among the ties
+	s.AddDependency("t03", "x")
+	s.Complete("x")
+
+	ready := s.ReadySet()
+	if popped := ids(s.PopDue(epoch)); !slices.Equal(ready, popped) {
+		t.Errorf("Expected ReadySet %v to match PopDue %v", ready, popped)
+	}
+	if ready[0] != "t00" || ready[3] != "t03" || ready[11] != "t11" {
+		t.Errorf("Expected ties in queueing order, got %v", ready)
+	}
+}
+
+func TestCompleteRefusesQueuedTask(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("setup", epoch.Add(time.Hour))
+	s.AddOrUpdate("job", epoch)
+	s.AddDependency("job", "setup")
+
+	if s.Complete("setup") {
+		t.Fatal("Expected Complete to refuse a task that has not run")
+	}
+	if due := s.PopDue(epoch); len(due) != 0 {
+		t.Errorf("Expected job to keep waiting, got %v", ids(due))
+	}
+
+	s.PopDue(epoch.Add(time.Hour))
+	if !s.Complete("setup") {
+		t.Fatal("Expected Complete to accept setup once it ran")
+	}
+	if due := s.PopDue(epoch.Add(time.Hour)); len(due) != 1 || due[0].ID != "job" {
+		t.Errorf("Expected job to be released, got %v", ids(due))
+	}
+}
+
+func TestCompleteRecurringOccurrence(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdateRecurring("tick", Recurring{Schedule: mustEvery(time.Minute)})
+	clock.Advance(time.Minute)
+	s.PopDueNow()
+
+	// The next occurrence is queued, but the one handed out has finished
+	if !s.Complete("tick") {
+		t.Error("Expected a recurring occurrence to complete")
+	}
+}
+
+func TestCompletionRetention(t *testing.T) {
+	s := CreateSchedulerWithClock(NewFakeClock(epoch))
+	s.SetCompletionRetention(3)
+
+	for i := 0; i < 1000; i++ {
+		s.Complete(fmt.Sprintf("job%d", i))
+		s.Complete("again")
+	}
+	if len(s.completed) > 3 || len(s.doneLog) > 3 {
+		t.Fatalf("Expected at most 3 completions kept, got %d and %d", len(s.completed), len(s.doneLog))
+	}
+
+	// Recent completions still satisfy new dependencies, old ones do not
+	s.AddOrUpdate("recent", epoch)
+	s.AddDependency("recent", "job999")
+	s.AddOrUpdate("old", epoch)
+	s.AddDependency("old", "job0")
+
+	if due := s.PopDue(epoch); len(due) != 1 || due[0].ID != "recent" {
+		t.Errorf("Expected only recent, got %v", ids(due))
+	}
+}
@@ -87,3 +87,19 @@ func (q *Deque) PeekBack() (int, error) {
 	v := q.get(q.back - 1)
 	return v, nil
 }
+
+// grow doubles the capacity of a full deque, keeping the elements in order,
+// for callers whose window size is not known up front.
+func (q *Deque) grow() {
+	n := q.Len()
+	buf := make([]int, max(2*q.capacity, 1))
+
+	for i := 0; i < n; i++ {
+		buf[i] = q.get(q.front + uint64(i))
+	}
+
+	q.buf = buf
+	q.capacity = len(buf)
+	q.front = 0
+	q.back = uint64(n)
+}
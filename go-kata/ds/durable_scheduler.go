@@ -0,0 +1,379 @@
+package ds
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	walFile      = "scheduler.wal"
+	snapshotFile = "scheduler.snapshot"
+)
+
+// walRecord is one line of the write-ahead log. Every record states the
+// result of an operation rather than the operation itself, so replaying it
+// over a state that already contains it changes nothing. That is what makes
+// a crash between writing a snapshot and truncating the log harmless.
+type walRecord struct {
+	Op   string   `json:"op"`
+	Task *Task    `json:"task,omitempty"`
+	IDs  []string `json:"ids,omitempty"`
+}
+
+const (
+	opPut    = "put"
+	opRemove = "remove"
+)
+
+// DurableScheduler is a Scheduler whose tasks survive a restart. Every change
+// is appended to a write-ahead log in dir and synced before it is applied;
+// every snapshotEvery records the whole heap is written to a snapshot and the
+// log starts over.
+//
+// Each log line is "<crc32> <json>". On open, the first line that is torn or
+// fails its checksum ends the log: it and everything after it are dropped.
+//
+// Payloads are stored as JSON and come back from a reopened scheduler as a
+// json.RawMessage. Recurring tasks are not supported.
+type DurableScheduler struct {
+	s   *Scheduler
+	dir string
+	wal *os.File
+
+	snapshotEvery int
+	records       int
+}
+
+func OpenDurableScheduler(dir string, snapshotEvery int) (*DurableScheduler, error) {
+	return OpenDurableSchedulerWithClock(dir, snapshotEvery, RealClock{})
+}
+
+// OpenDurableSchedulerWithClock opens (or creates) the scheduler stored in
+// dir. snapshotEvery <= 0 only snapshots when Snapshot is called.
+func OpenDurableSchedulerWithClock(dir string, snapshotEvery int, clock Clock) (*DurableScheduler, error) {
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	d := &DurableScheduler{
+		s:             CreateSchedulerWithClock(clock),
+		dir:           dir,
+		snapshotEvery: snapshotEvery,
+	}
+
+	if err := d.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(dir, walFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	d.wal = wal
+
+	if err := d.replay(); err != nil {
+		wal.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *DurableScheduler) loadSnapshot() error {
+
+	data, err := os.ReadFile(filepath.Join(d.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var tasks []*Task
+	if err := json.Unmarshal(data, &tasks); err != nil {
+		return fmt.Errorf("Corrupt snapshot: %w", err)
+	}
+
+	for _, t := range tasks {
+		d.s.put(t)
+	}
+
+	return nil
+}
+
+// replay applies every intact record in the log and truncates the log after
+// the last one.
+func (d *DurableScheduler) replay() error {
+
+	if _, err := d.wal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(d.wal)
+	var good int64
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rec, ok := decodeRecord(line)
+		if !ok {
+			break
+		}
+
+		d.apply(rec)
+		good += int64(len(line))
+		d.records++
+	}
+
+	return d.wal.Truncate(good)
+}
+
+func encodeRecord(rec walRecord) ([]byte, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "%08x %s\n", crc32.ChecksumIEEE(data), data), nil
+}
+
+func decodeRecord(line []byte) (walRecord, bool) {
+	var rec walRecord
+
+	sum, data, found := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !found || len(sum) != 8 {
+		return rec, false
+	}
+
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || crc32.ChecksumIEEE(data) != uint32(want) {
+		return rec, false
+	}
+
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return rec, false
+	}
+	if rec.Op == opPut && rec.Task == nil {
+		return rec, false
+	}
+
+	return rec, true
+}
+
+func (d *DurableScheduler) apply(rec walRecord) {
+	switch rec.Op {
+	case opPut:
+		d.s.put(rec.Task)
+	case opRemove:
+		for _, id := range rec.IDs {
+			d.s.Remove(id)
+		}
+	}
+}
+
+// put inserts t, or overwrites every field of the task with the same ID.
+func (s *Scheduler) put(t *Task) {
+
+	if old, ok := s.byID[t.ID]; ok {
+		seq := old.seq
+		*old = *t
+		old.seq = seq
+		old.recur = nil
+		s.ready.Set(t.ID, old)
+		return
+	}
+
+	t.recur = nil
+	s.stamp(t)
+	s.byID[t.ID] = t
+	s.ready.Set(t.ID, t)
+}
+
+func (d *DurableScheduler) append(rec walRecord) error {
+
+	line, err := encodeRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.wal.Write(line); err != nil {
+		return err
+	}
+	if err := d.wal.Sync(); err != nil {
+		return err
+	}
+
+	d.records++
+
+	return nil
+}
+
+// maybeSnapshot runs after a change has been applied. A failed snapshot does
+// not lose anything, since the log still holds every record.
+func (d *DurableScheduler) maybeSnapshot() error {
+	if d.snapshotEvery <= 0 || d.records < d.snapshotEvery {
+		return nil
+	}
+	return d.Snapshot()
+}
+
+// AddOrUpdate is Scheduler.AddOrUpdate, logged before it is applied.
+func (d *DurableScheduler) AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption) error {
+
+	now := d.s.clock.Now()
+
+	t := &Task{ID: ID, created: now}
+	if old, ok := d.s.byID[ID]; ok {
+		*t = *old
+		t.Labels = maps.Clone(old.Labels)
+	}
+	t.deadline = deadline
+	t.updated = now
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if err := d.append(walRecord{Op: opPut, Task: t}); err != nil {
+		return err
+	}
+	d.s.put(t)
+
+	return d.maybeSnapshot()
+}
+
+func (d *DurableScheduler) AddOrUpdateAfter(ID string, delay time.Duration, opts ...TaskOption) error {
+	return d.AddOrUpdate(ID, d.s.clock.Now().Add(delay), opts...)
+}
+
+func (d *DurableScheduler) Remove(ID string) (bool, error) {
+
+	if _, ok := d.s.byID[ID]; !ok {
+		return false, nil
+	}
+
+	if err := d.append(walRecord{Op: opRemove, IDs: []string{ID}}); err != nil {
+		return false, err
+	}
+	d.s.Remove(ID)
+
+	return true, d.maybeSnapshot()
+}
+
+func (d *DurableScheduler) PopDue(deadline time.Time) ([]*Task, error) {
+	return d.PopDueN(deadline, 0)
+}
+
+// PopDueN pops like Scheduler.PopDueN and logs the popped IDs. If the log
+// write fails the tasks are put back and none are returned.
+func (d *DurableScheduler) PopDueN(deadline time.Time, limit int) ([]*Task, error) {
+
+	due := d.s.PopDueN(deadline, limit)
+	if len(due) == 0 {
+		return due, nil
+	}
+
+	ids := make([]string, 0, len(due))
+	for _, t := range due {
+		ids = append(ids, t.ID)
+	}
+
+	if err := d.append(walRecord{Op: opRemove, IDs: ids}); err != nil {
+		for _, t := range due {
+			d.s.put(t)
+		}
+		return nil, err
+	}
+
+	return due, d.maybeSnapshot()
+}
+
+func (d *DurableScheduler) PopDueNow() ([]*Task, error) {
+	return d.PopDue(d.s.clock.Now())
+}
+
+func (d *DurableScheduler) Len() int {
+	return d.s.ready.Len()
+}
+
+// Snapshot writes every queued task to a new snapshot file, swaps it in with
+// a rename and then empties the log.
+func (d *DurableScheduler) Snapshot() error {
+
+	tasks := make([]*Task, 0, d.s.ready.Len())
+	for _, t := range d.s.ready.All() {
+		tasks = append(tasks, t)
+	}
+
+	data, err := json.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(d.dir, snapshotFile+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err := syncDir(d.dir); err != nil {
+		return err
+	}
+
+	// A crash before this point replays the old log over the new snapshot,
+	// which is safe because records are idempotent
+	if err := d.wal.Truncate(0); err != nil {
+		return err
+	}
+	if err := d.wal.Sync(); err != nil {
+		return err
+	}
+	d.records = 0
+
+	return nil
+}
+
+func (d *DurableScheduler) Close() error {
+	return d.wal.Close()
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
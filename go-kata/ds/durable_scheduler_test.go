@@ -0,0 +1,241 @@
+package ds
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openDurable(t *testing.T, dir string, snapshotEvery int) *DurableScheduler {
+	t.Helper()
+
+	d, err := OpenDurableSchedulerWithClock(dir, snapshotEvery, NewFakeClock(epoch))
+	if err != nil {
+		t.Fatalf("Failed to open durable scheduler: %v", err)
+	}
+	return d
+}
+
+// durableState summarises the queued tasks as ID -> deadline.
+func durableState(d *DurableScheduler) map[string]time.Time {
+	state := make(map[string]time.Time, len(d.s.byID))
+	for id, task := range d.s.byID {
+		state[id] = task.deadline
+	}
+	return state
+}
+
+func sameState(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id, deadline := range a {
+		if other, ok := b[id]; !ok || !other.Equal(deadline) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDurableSchedulerRecovers(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir, 0)
+
+	if err := d.AddOrUpdate("a", epoch.Add(time.Minute), WithPayload(map[string]int{"n": 1}), WithPriority(3)); err != nil {
+		t.Fatal(err)
+	}
+	d.AddOrUpdate("b", epoch.Add(2*time.Minute), WithLabel("team", "ops"))
+	d.AddOrUpdate("c", epoch.Add(3*time.Minute))
+	d.AddOrUpdate("d", epoch.Add(4*time.Minute))
+	d.AddOrUpdate("b", epoch.Add(5*time.Minute))
+
+	if removed, err := d.Remove("c"); !removed || err != nil {
+		t.Fatalf("Expected c to be removed, got %v, %v", removed, err)
+	}
+
+	due, err := d.PopDue(epoch.Add(4 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := ids(due); len(ids) != 2 || ids[0] != "a" || ids[1] != "d" {
+		t.Fatalf("Expected [a d] to be due, got %v", ids)
+	}
+
+	d.AddOrUpdate("a", epoch.Add(6*time.Minute), WithPayload(map[string]int{"n": 2}))
+
+	want := durableState(d)
+	d.Close()
+
+	d = openDurable(t, dir, 0)
+	defer d.Close()
+
+	if got := durableState(d); !sameState(got, want) {
+		t.Fatalf("Expected %v after reopening, got %v", want, got)
+	}
+
+	b := d.s.byID["b"]
+	if b.Labels["team"] != "ops" {
+		t.Errorf("Expected b to keep its label, got %v", b.Labels)
+	}
+	if !b.Created().Equal(epoch) {
+		t.Errorf("Expected b to keep its created time, got %v", b.Created())
+	}
+
+	raw, ok := PayloadAs[json.RawMessage](d.s.byID["a"])
+	if !ok {
+		t.Fatalf("Expected a raw JSON payload, got %T", d.s.byID["a"].Payload)
+	}
+	var payload map[string]int
+	if err := json.Unmarshal(raw, &payload); err != nil || payload["n"] != 2 {
+		t.Errorf("Expected payload {n:2}, got %s", raw)
+	}
+}
+
+func TestDurableSchedulerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir, 4)
+
+	for i, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		d.AddOrUpdate(id, epoch.Add(time.Duration(i)*time.Minute))
+	}
+	d.Remove("b")
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil {
+		t.Fatalf("Expected a snapshot after 4 records: %v", err)
+	}
+	if d.records != 3 {
+		t.Errorf("Expected 3 records in the log since the snapshot, got %d", d.records)
+	}
+
+	want := durableState(d)
+	d.Close()
+
+	d = openDurable(t, dir, 4)
+	defer d.Close()
+
+	if got := durableState(d); !sameState(got, want) {
+		t.Errorf("Expected %v after reopening, got %v", want, got)
+	}
+
+	if err := d.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(dir, walFile)); info.Size() != 0 {
+		t.Errorf("Expected an empty log after Snapshot, got %d bytes", info.Size())
+	}
+}
+
+func TestDurableSchedulerReplaysLogOverSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir, 0)
+
+	d.AddOrUpdate("a", epoch.Add(time.Minute))
+	d.AddOrUpdate("b", epoch.Add(2*time.Minute))
+	d.Remove("a")
+	d.AddOrUpdate("b", epoch.Add(3*time.Minute))
+
+	log, _ := os.ReadFile(filepath.Join(dir, walFile))
+
+	want := durableState(d)
+	d.Snapshot()
+	d.Close()
+
+	// Simulate a crash after the snapshot was renamed into place but
+	// before the log was truncated
+	os.WriteFile(filepath.Join(dir, walFile), log, 0o644)
+
+	d = openDurable(t, dir, 0)
+	defer d.Close()
+
+	if got := durableState(d); !sameState(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDurableSchedulerTornLog(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir, 0)
+
+	// states[i] is the state after the first i records
+	states := []map[string]time.Time{durableState(d)}
+	step := func(f func()) {
+		f()
+		states = append(states, durableState(d))
+	}
+
+	step(func() { d.AddOrUpdate("a", epoch.Add(time.Minute)) })
+	step(func() { d.AddOrUpdate("b", epoch.Add(2*time.Minute), WithPayload("hello")) })
+	step(func() { d.AddOrUpdate("c", epoch.Add(3*time.Minute)) })
+	step(func() { d.AddOrUpdate("a", epoch.Add(4*time.Minute)) })
+	step(func() { d.Remove("c") })
+	step(func() { d.PopDue(epoch.Add(2 * time.Minute)) })
+	step(func() { d.AddOrUpdate("d", epoch.Add(5*time.Minute)) })
+	d.Close()
+
+	log, err := os.ReadFile(filepath.Join(dir, walFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for offset := 0; offset <= len(log); offset++ {
+		prefix := log[:offset]
+		complete := bytes.Count(prefix, []byte("\n"))
+
+		crashed := t.TempDir()
+		os.WriteFile(filepath.Join(crashed, walFile), prefix, 0o644)
+
+		d := openDurable(t, crashed, 0)
+
+		if got := durableState(d); !sameState(got, states[complete]) {
+			t.Fatalf("Offset %d: expected %v, got %v", offset, states[complete], got)
+		}
+
+		// The torn record is gone and new records land after the last
+		// intact one
+		if err := d.AddOrUpdate("z", epoch); err != nil {
+			t.Fatalf("Offset %d: %v", offset, err)
+		}
+		d.Close()
+
+		d = openDurable(t, crashed, 0)
+		if _, ok := d.s.byID["z"]; !ok {
+			t.Fatalf("Offset %d: record written after recovery was lost", offset)
+		}
+		if len(d.s.byID) != len(states[complete])+1 {
+			t.Fatalf("Offset %d: expected %d tasks, got %d", offset, len(states[complete])+1, len(d.s.byID))
+		}
+		d.Close()
+	}
+}
+
+func TestDurableSchedulerCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	d := openDurable(t, dir, 0)
+
+	d.AddOrUpdate("a", epoch.Add(time.Minute))
+	d.AddOrUpdate("b", epoch.Add(2*time.Minute))
+	d.AddOrUpdate("c", epoch.Add(3*time.Minute))
+	d.Close()
+
+	path := filepath.Join(dir, walFile)
+	log, _ := os.ReadFile(path)
+
+	// Flip a byte inside the second record's JSON
+	lines := bytes.SplitAfter(log, []byte("\n"))
+	second := len(lines[0]) + 20
+	log[second] ^= 0xff
+	os.WriteFile(path, log, 0o644)
+
+	d = openDurable(t, dir, 0)
+	defer d.Close()
+
+	if got := durableState(d); len(got) != 1 || !got["a"].Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Expected only a to survive, got %v", got)
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(lines[0])) {
+		t.Errorf("Expected the log to be truncated to %d bytes, got %d", len(lines[0]), info.Size())
+	}
+}
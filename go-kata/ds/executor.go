@@ -0,0 +1,219 @@
+package ds
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrExecutorClosed = errors.New("Executor is shut down!!")
+	ErrNilTask        = errors.New("Task function is nil!!")
+)
+
+type job struct {
+	task *Task
+	fn   func()
+}
+
+// Executor owns a Scheduler and runs each task's callback once its deadline
+// passes. A single loop sleeps on one timer set to the earliest deadline and
+// hands due tasks to a fixed pool of workers; AddOrUpdate and Remove only
+// wake the loop when they change the earliest deadline.
+type Executor struct {
+	mu     sync.Mutex
+	s      *Scheduler
+	funcs  map[string]func()
+	closed bool
+
+	wake chan struct{}
+	jobs chan job
+	quit chan struct{}
+	done chan struct{}
+
+	workers sync.WaitGroup
+}
+
+func NewExecutor(workers int) *Executor {
+	return NewExecutorWithClock(workers, RealClock{})
+}
+
+func NewExecutorWithClock(workers int, clock Clock) *Executor {
+	e := &Executor{
+		s:     CreateSchedulerWithClock(clock),
+		funcs: make(map[string]func()),
+		wake:  make(chan struct{}, 1),
+		jobs:  make(chan job),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	for i := 0; i < max(workers, 1); i++ {
+		e.workers.Add(1)
+		go e.work()
+	}
+
+	go e.run()
+
+	return e
+}
+
+// head reports the task at the root of the heap. Callers hold e.mu.
+func (e *Executor) head() (string, time.Time, bool) {
+	t, ok := e.s.head()
+	if !ok {
+		return "", time.Time{}, false
+	}
+	return t.ID, t.deadline, true
+}
+
+// notifyIfHeadChanged wakes the loop when the earliest deadline moved.
+// Callers hold e.mu.
+func (e *Executor) notifyIfHeadChanged(id string, deadline time.Time, ok bool) {
+	new_id, new_deadline, new_ok := e.head()
+	if new_ok == ok && new_id == id && new_deadline.Equal(deadline) {
+		return
+	}
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// AddOrUpdate schedules fn to run at deadline, replacing any task with the
+// same ID that has not started yet. A nil fn is rejected with ErrNilTask.
+func (e *Executor) AddOrUpdate(ID string, deadline time.Time, fn func()) error {
+
+	if fn == nil {
+		return ErrNilTask
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrExecutorClosed
+	}
+
+	id, old_deadline, ok := e.head()
+
+	e.s.AddOrUpdate(ID, deadline)
+	e.funcs[ID] = fn
+
+	e.notifyIfHeadChanged(id, old_deadline, ok)
+
+	return nil
+}
+
+// Remove cancels a task that has not been handed to a worker yet.
+func (e *Executor) Remove(ID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id, deadline, ok := e.head()
+
+	if !e.s.Remove(ID) {
+		return false
+	}
+	delete(e.funcs, ID)
+
+	e.notifyIfHeadChanged(id, deadline, ok)
+
+	return true
+}
+
+func (e *Executor) run() {
+	defer close(e.done)
+
+	clock := e.s.clock
+
+	timer := clock.NewTimer(0)
+	timer.Stop()
+
+	for {
+		e.mu.Lock()
+		_, next, ok := e.head()
+		e.mu.Unlock()
+
+		if ok {
+			timer.Reset(next.Sub(clock.Now()))
+		} else {
+			timer.Stop()
+		}
+
+		select {
+		case <-e.quit:
+			timer.Stop()
+			return
+		case <-e.wake:
+		case <-timer.C():
+			if !e.dispatch() {
+				return
+			}
+		}
+	}
+}
+
+// dispatch hands every due task to the workers. It returns false if the
+// executor was shut down while waiting for a free worker.
+func (e *Executor) dispatch() bool {
+	e.mu.Lock()
+	due := e.s.PopDueNow()
+	jobs := make([]job, 0, len(due))
+	for _, t := range due {
+		jobs = append(jobs, job{task: t, fn: e.funcs[t.ID]})
+		delete(e.funcs, t.ID)
+	}
+	e.mu.Unlock()
+
+	for _, j := range jobs {
+		select {
+		case e.jobs <- j:
+		case <-e.quit:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Executor) work() {
+	defer e.workers.Done()
+
+	for j := range e.jobs {
+		j.fn()
+	}
+}
+
+// Shutdown stops accepting and dispatching tasks, then waits for callbacks
+// that are already running. Tasks that have not started are dropped. If ctx
+// ends first, Shutdown returns its error and the running callbacks are left
+// to finish on their own.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return ErrExecutorClosed
+	}
+	e.closed = true
+	close(e.quit)
+	e.mu.Unlock()
+
+	<-e.done
+	close(e.jobs)
+
+	finished := make(chan struct{})
+	go func() {
+		e.workers.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
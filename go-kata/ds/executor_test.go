@@ -0,0 +1,231 @@
+package ds
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExecutorRunsTasksInDeadlineOrder(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Shutdown(context.Background())
+
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{})
+
+	record := func(id string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, id)
+			if len(order) == 3 {
+				close(done)
+			}
+			mu.Unlock()
+		}
+	}
+
+	now := time.Now()
+	e.AddOrUpdate("c", now.Add(30*time.Millisecond), record("c"))
+	e.AddOrUpdate("a", now.Add(10*time.Millisecond), record("a"))
+	e.AddOrUpdate("b", now.Add(20*time.Millisecond), record("b"))
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for tasks to run")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Errorf("Expected order [a b c], got %v", order)
+	}
+}
+
+func TestExecutorEarlierDeadlineWakesLoop(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Shutdown(context.Background())
+
+	ran := make(chan string, 2)
+
+	// The loop is sleeping towards an hour from now when the update arrives
+	e.AddOrUpdate("late", time.Now().Add(time.Hour), func() { ran <- "late" })
+	e.AddOrUpdate("soon", time.Now().Add(10*time.Millisecond), func() { ran <- "soon" })
+
+	select {
+	case id := <-ran:
+		if id != "soon" {
+			t.Errorf("Expected soon to run, got %s", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Earlier deadline did not wake the loop")
+	}
+}
+
+func TestExecutorRemove(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Shutdown(context.Background())
+
+	ran := make(chan string, 2)
+
+	e.AddOrUpdate("removed", time.Now().Add(20*time.Millisecond), func() { ran <- "removed" })
+	e.AddOrUpdate("kept", time.Now().Add(40*time.Millisecond), func() { ran <- "kept" })
+
+	if !e.Remove("removed") {
+		t.Fatal("Expected Remove to find the task")
+	}
+	if e.Remove("missing") {
+		t.Error("Expected Remove of unknown task to return false")
+	}
+
+	select {
+	case id := <-ran:
+		if id != "kept" {
+			t.Errorf("Expected only kept to run, got %s", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for kept")
+	}
+}
+
+func TestExecutorBoundedWorkers(t *testing.T) {
+	e := NewExecutor(2)
+	defer e.Shutdown(context.Background())
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		e.AddOrUpdate(string(rune('a'+i)), now, func() {
+			defer wg.Done()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		})
+	}
+
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent tasks, got %d", peak.Load())
+	}
+}
+
+func TestExecutorShutdown(t *testing.T) {
+	t.Run("Waits for running tasks", func(t *testing.T) {
+		e := NewExecutor(1)
+
+		started := make(chan struct{})
+		var finished atomic.Bool
+		e.AddOrUpdate("slow", time.Now(), func() {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			finished.Store(true)
+		})
+
+		<-started
+		if err := e.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !finished.Load() {
+			t.Error("Shutdown returned before the running task finished")
+		}
+
+		if err := e.AddOrUpdate("late", time.Now(), func() {}); err != ErrExecutorClosed {
+			t.Errorf("Expected ErrExecutorClosed, got %v", err)
+		}
+		if err := e.Shutdown(context.Background()); err != ErrExecutorClosed {
+			t.Errorf("Expected ErrExecutorClosed on second Shutdown, got %v", err)
+		}
+	})
+
+	t.Run("Gives up when the context ends", func(t *testing.T) {
+		e := NewExecutor(1)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		e.AddOrUpdate("stuck", time.Now(), func() {
+			close(started)
+			<-release
+		})
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := e.Shutdown(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Pending tasks are dropped", func(t *testing.T) {
+		e := NewExecutor(1)
+
+		var ran atomic.Bool
+		e.AddOrUpdate("future", time.Now().Add(time.Hour), func() { ran.Store(true) })
+
+		if err := e.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ran.Load() {
+			t.Error("Future task should not have run")
+		}
+	})
+}
+
+func TestExecutorWithFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := NewExecutorWithClock(1, clock)
+	defer e.Shutdown(context.Background())
+
+	ran := make(chan string, 2)
+
+	e.AddOrUpdate("a", clock.Now().Add(time.Minute), func() { ran <- "a" })
+	e.AddOrUpdate("b", clock.Now().Add(time.Hour), func() { ran <- "b" })
+
+	// Wait for the loop to arm its timer for a
+	clock.BlockUntil(1)
+
+	select {
+	case id := <-ran:
+		t.Fatalf("%s ran before the clock moved", id)
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	if id := <-ran; id != "a" {
+		t.Errorf("Expected a to run, got %s", id)
+	}
+
+	// The loop re-arms for b
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Minute)
+	if id := <-ran; id != "b" {
+		t.Errorf("Expected b to run, got %s", id)
+	}
+}
+
+func TestExecutorRejectsNilFunc(t *testing.T) {
+	e := NewExecutor(1)
+	defer e.Shutdown(context.Background())
+
+	if err := e.AddOrUpdate("nil", time.Now(), nil); err != ErrNilTask {
+		t.Fatalf("Expected ErrNilTask, got %v", err)
+	}
+	if e.Remove("nil") {
+		t.Error("Expected nothing to be scheduled")
+	}
+}
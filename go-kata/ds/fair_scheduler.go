@@ -0,0 +1,225 @@
+package ds
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("Tenant quota exceeded!!")
+
+// TenantLabel is set on every task a FairScheduler hands out, naming the
+// tenant it belongs to.
+const TenantLabel = "tenant"
+
+// TenantConfig sets a tenant's share. Weight is how many due tasks the
+// tenant may take per round when several tenants have due tasks (at least
+// 1). Quota caps how many tasks it may have queued; 0 means no cap.
+type TenantConfig struct {
+	Weight int
+	Quota  int
+}
+
+// TenantStats reports how a tenant is being served. Lag is how long after
+// its deadline a task was handed out.
+type TenantStats struct {
+	Queued   int
+	Popped   int
+	Rejected int
+	TotalLag time.Duration
+	MaxLag   time.Duration
+}
+
+func (ts TenantStats) MeanLag() time.Duration {
+	if ts.Popped == 0 {
+		return 0
+	}
+	return ts.TotalLag / time.Duration(ts.Popped)
+}
+
+type tenantQueue struct {
+	s       *Scheduler
+	config  TenantConfig
+	deficit int
+	stats   TenantStats
+}
+
+// FairScheduler keeps a Scheduler per tenant and shares out due tasks with
+// deficit round-robin: each round, every tenant with due tasks is credited
+// its weight and may take that many, earliest deadline first. A tenant that
+// floods the scheduler only delays its own tasks.
+//
+// Fairness shows when pops are capped with PopDueN; the round-robin position
+// and unused credit carry over between calls.
+type FairScheduler struct {
+	clock   Clock
+	tenants map[string]*tenantQueue
+	order   []string
+
+	next     int  // position in order of the tenant being served
+	credited bool // whether that tenant already got its credit this round
+}
+
+func NewFairScheduler() *FairScheduler {
+	return NewFairSchedulerWithClock(RealClock{})
+}
+
+func NewFairSchedulerWithClock(clock Clock) *FairScheduler {
+	return &FairScheduler{
+		clock:   clock,
+		tenants: make(map[string]*tenantQueue),
+	}
+}
+
+// tenant returns the queue for name, creating it with weight 1 and no quota.
+func (f *FairScheduler) tenant(name string) *tenantQueue {
+	if q, ok := f.tenants[name]; ok {
+		return q
+	}
+
+	q := &tenantQueue{s: CreateSchedulerWithClock(f.clock), config: TenantConfig{Weight: 1}}
+	f.tenants[name] = q
+	f.order = append(f.order, name)
+
+	return q
+}
+
+// SetTenant configures a tenant, creating it if needed. Lowering a quota
+// does not drop tasks that are already queued.
+func (f *FairScheduler) SetTenant(name string, config TenantConfig) {
+	config.Weight = max(config.Weight, 1)
+	f.tenant(name).config = config
+}
+
+// AddOrUpdate schedules ID for tenant. Task IDs are scoped to their tenant.
+// A new task is rejected with ErrQuotaExceeded if the tenant is at its
+// quota; updating a queued one always succeeds.
+func (f *FairScheduler) AddOrUpdate(tenant, ID string, deadline time.Time, opts ...TaskOption) error {
+
+	q := f.tenant(tenant)
+
+	if _, queued := q.s.byID[ID]; !queued && q.config.Quota > 0 && q.s.Len() >= q.config.Quota {
+		q.stats.Rejected++
+		return ErrQuotaExceeded
+	}
+
+	opts = append(opts[:len(opts):len(opts)], WithLabel(TenantLabel, tenant))
+	q.s.AddOrUpdate(ID, deadline, opts...)
+
+	return nil
+}
+
+func (f *FairScheduler) Remove(tenant, ID string) bool {
+	q, ok := f.tenants[tenant]
+	if !ok {
+		return false
+	}
+	return q.s.Remove(ID)
+}
+
+func (f *FairScheduler) PopDue(deadline time.Time) []*Task {
+	return f.PopDueN(deadline, 0)
+}
+
+func (q *tenantQueue) hasDue(deadline time.Time) bool {
+	next, ok := q.s.NextDeadline()
+	return ok && !next.After(deadline)
+}
+
+// PopDueN hands out up to limit due tasks across tenants by deficit
+// round-robin; limit <= 0 means no cap.
+func (f *FairScheduler) PopDueN(deadline time.Time, limit int) []*Task {
+
+	var due []*Task = make([]*Task, 0)
+
+	now := f.clock.Now()
+	idle := 0 // tenants visited in a row with nothing due
+
+	for len(f.order) > 0 && idle < len(f.order) && (limit <= 0 || len(due) < limit) {
+
+		q := f.tenants[f.order[f.next]]
+
+		if !q.hasDue(deadline) {
+			q.deficit = 0
+			f.advance()
+			idle++
+			continue
+		}
+		idle = 0
+
+		if !f.credited {
+			q.deficit += q.config.Weight
+			f.credited = true
+		}
+
+		for q.deficit > 0 && q.hasDue(deadline) && (limit <= 0 || len(due) < limit) {
+			t, ok := q.s.PopOne(deadline)
+			if !ok {
+				// A skipped recurring occurrence costs nothing
+				continue
+			}
+			q.deficit--
+			q.record(t, now)
+			due = append(due, t)
+		}
+
+		// Out of room: stay on this tenant so the next call resumes
+		// with its remaining credit
+		if limit > 0 && len(due) >= limit && q.deficit > 0 && q.hasDue(deadline) {
+			break
+		}
+
+		if !q.hasDue(deadline) {
+			q.deficit = 0
+		}
+		f.advance()
+	}
+
+	return due
+}
+
+func (f *FairScheduler) advance() {
+	f.next = (f.next + 1) % len(f.order)
+	f.credited = false
+}
+
+func (q *tenantQueue) record(t *Task, now time.Time) {
+	lag := max(now.Sub(t.deadline), 0)
+
+	q.stats.Popped++
+	q.stats.TotalLag += lag
+	q.stats.MaxLag = max(q.stats.MaxLag, lag)
+}
+
+func (f *FairScheduler) PopDueNow() []*Task {
+	return f.PopDue(f.clock.Now())
+}
+
+// Stats returns a tenant's counters and lag.
+func (f *FairScheduler) Stats(tenant string) (TenantStats, bool) {
+	q, ok := f.tenants[tenant]
+	if !ok {
+		return TenantStats{}, false
+	}
+
+	stats := q.stats
+	stats.Queued = q.s.Len()
+
+	return stats, true
+}
+
+// Tenants returns the known tenant names, sorted.
+func (f *FairScheduler) Tenants() []string {
+	names := make([]string, 0, len(f.order))
+	names = append(names, f.order...)
+	sort.Strings(names)
+	return names
+}
+
+func (f *FairScheduler) Len() int {
+	n := 0
+	for _, q := range f.tenants {
+		n += q.s.Len()
+	}
+	return n
+}
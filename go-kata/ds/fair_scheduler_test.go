@@ -0,0 +1,165 @@
+package ds
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// countTenants tallies the tenant label of each task.
+func countTenants(tasks []*Task) map[string]int {
+	counts := make(map[string]int)
+	for _, t := range tasks {
+		counts[t.Labels[TenantLabel]]++
+	}
+	return counts
+}
+
+func TestFairSchedulerFloodingTenant(t *testing.T) {
+	f := NewFairSchedulerWithClock(NewFakeClock(epoch))
+
+	// The flooder's tasks are all earlier, so a single heap would hand
+	// out nothing else
+	for i := 0; i < 100; i++ {
+		f.AddOrUpdate("flood", fmt.Sprintf("f%d", i), epoch.Add(-time.Hour))
+	}
+	for i := 0; i < 5; i++ {
+		f.AddOrUpdate("quiet", fmt.Sprintf("q%d", i), epoch)
+	}
+
+	due := f.PopDueN(epoch, 10)
+
+	counts := countTenants(due)
+	if counts["flood"] != 5 || counts["quiet"] != 5 {
+		t.Errorf("Expected 5 tasks each, got %v", counts)
+	}
+
+	// Once the quiet tenant is drained the flooder gets everything
+	due = f.PopDueN(epoch, 10)
+	if counts := countTenants(due); counts["flood"] != 10 {
+		t.Errorf("Expected 10 flood tasks, got %v", counts)
+	}
+}
+
+func TestFairSchedulerWeights(t *testing.T) {
+	f := NewFairScheduler()
+	now := time.Now()
+
+	f.SetTenant("gold", TenantConfig{Weight: 3})
+	f.SetTenant("bronze", TenantConfig{Weight: 1})
+
+	for i := 0; i < 50; i++ {
+		f.AddOrUpdate("gold", fmt.Sprintf("g%d", i), now)
+		f.AddOrUpdate("bronze", fmt.Sprintf("b%d", i), now)
+	}
+
+	counts := countTenants(f.PopDueN(now, 20))
+	if counts["gold"] != 15 || counts["bronze"] != 5 {
+		t.Errorf("Expected a 3:1 split, got %v", counts)
+	}
+}
+
+func TestFairSchedulerResumesAcrossCalls(t *testing.T) {
+	f := NewFairScheduler()
+	now := time.Now()
+
+	f.SetTenant("a", TenantConfig{Weight: 2})
+	for i := 0; i < 10; i++ {
+		f.AddOrUpdate("a", fmt.Sprintf("a%d", i), now)
+		f.AddOrUpdate("b", fmt.Sprintf("b%d", i), now)
+	}
+
+	var order []string
+	for i := 0; i < 9; i++ {
+		due := f.PopDueN(now, 1)
+		order = append(order, due[0].Labels[TenantLabel])
+	}
+
+	expected := []string{"a", "a", "b", "a", "a", "b", "a", "a", "b"}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestFairSchedulerOrdersWithinTenant(t *testing.T) {
+	f := NewFairScheduler()
+	now := time.Now()
+
+	f.AddOrUpdate("a", "late", now)
+	f.AddOrUpdate("a", "early", now.Add(-time.Minute))
+	f.AddOrUpdate("a", "future", now.Add(time.Minute))
+
+	due := f.PopDue(now)
+	if got := ids(due); len(got) != 2 || got[0] != "early" || got[1] != "late" {
+		t.Errorf("Expected [early late], got %v", got)
+	}
+}
+
+func TestFairSchedulerQuota(t *testing.T) {
+	f := NewFairScheduler()
+	now := time.Now()
+
+	f.SetTenant("small", TenantConfig{Quota: 2})
+
+	f.AddOrUpdate("small", "a", now)
+	f.AddOrUpdate("small", "b", now)
+
+	if err := f.AddOrUpdate("small", "c", now); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := f.AddOrUpdate("small", "a", now.Add(time.Second)); err != nil {
+		t.Errorf("Expected an update at quota to succeed, got %v", err)
+	}
+
+	// IDs are per tenant, and other tenants are unaffected
+	if err := f.AddOrUpdate("other", "c", now); err != nil {
+		t.Errorf("Expected another tenant to be unaffected, got %v", err)
+	}
+
+	stats, _ := f.Stats("small")
+	if stats.Queued != 2 || stats.Rejected != 1 {
+		t.Errorf("Expected 2 queued and 1 rejected, got %+v", stats)
+	}
+
+	if !f.Remove("small", "a") || f.Remove("missing", "a") {
+		t.Error("Expected Remove to find only queued tasks")
+	}
+	if err := f.AddOrUpdate("small", "c", now); err != nil {
+		t.Errorf("Expected room after Remove, got %v", err)
+	}
+}
+
+func TestFairSchedulerLag(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	f := NewFairSchedulerWithClock(clock)
+
+	f.AddOrUpdate("a", "one", epoch.Add(time.Second))
+	f.AddOrUpdate("a", "two", epoch.Add(3*time.Second))
+	f.AddOrUpdate("b", "three", epoch.Add(5*time.Second))
+
+	clock.Advance(5 * time.Second)
+	f.PopDueNow()
+
+	stats, ok := f.Stats("a")
+	if !ok {
+		t.Fatal("Expected stats for tenant a")
+	}
+	if stats.Popped != 2 || stats.MaxLag != 4*time.Second || stats.MeanLag() != 3*time.Second {
+		t.Errorf("Expected 2 popped, max lag 4s, mean 3s, got %+v", stats)
+	}
+
+	stats, _ = f.Stats("b")
+	if stats.MaxLag != 0 {
+		t.Errorf("Expected no lag for b, got %v", stats.MaxLag)
+	}
+
+	if got := f.Tenants(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected tenants [a b], got %v", got)
+	}
+	if f.Len() != 0 {
+		t.Errorf("Expected nothing queued, got %d", f.Len())
+	}
+}
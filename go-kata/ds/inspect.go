@@ -0,0 +1,143 @@
+package ds
+
+import (
+	"iter"
+	"maps"
+	"sort"
+	"time"
+)
+
+// copyTask returns a detached copy of t that callers may keep or modify.
+func copyTask(t *Task) *Task {
+	c := *t
+	c.Labels = maps.Clone(t.Labels)
+	c.recur = nil
+	return &c
+}
+
+// Get returns a copy of the queued or blocked task with ID.
+func (s *Scheduler) Get(ID string) (*Task, bool) {
+	t, ok := s.byID[ID]
+	if !ok {
+		return nil, false
+	}
+	return copyTask(t), true
+}
+
+// Len returns the number of tasks held, including blocked ones.
+func (s *Scheduler) Len() int {
+	return len(s.byID)
+}
+
+// RunNow moves ID's deadline to the scheduler's current time, keeping its
+// other fields, so the next PopDue hands it out. A blocked task still waits
+// for its prerequisites, and a recurring task carries on with its schedule
+// afterwards.
+func (s *Scheduler) RunNow(ID string) bool {
+	t, ok := s.byID[ID]
+	if !ok {
+		return false
+	}
+
+	t.deadline = s.clock.Now()
+	t.updated = t.deadline
+	if _, ok := s.ready.Get(ID); ok {
+		s.ready.Set(ID, t)
+	}
+
+	return true
+}
+
+// NextDeadline returns the deadline of the task PopDue would hand out next.
+func (s *Scheduler) NextDeadline() (time.Time, bool) {
+	t, ok := s.head()
+	if !ok {
+		return time.Time{}, false
+	}
+	return t.deadline, true
+}
+
+// walk yields queued tasks in deadline order, stopping at the first one
+// after until when bounded.
+func (s *Scheduler) walk(until time.Time, bounded bool) iter.Seq[*Task] {
+	return func(yield func(*Task) bool) {
+		for _, t := range s.ready.All() {
+			if bounded && t.deadline.After(until) {
+				return
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// All yields copies of the ready tasks in the order PopDue would hand them
+// out. The scheduler must not be modified during the iteration.
+func (s *Scheduler) All() iter.Seq[*Task] {
+	return func(yield func(*Task) bool) {
+		for t := range s.walk(time.Time{}, false) {
+			if !yield(copyTask(t)) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns copies of the ready tasks due between from and to,
+// inclusive, in deadline order.
+func (s *Scheduler) Range(from, to time.Time) []*Task {
+
+	tasks := make([]*Task, 0)
+
+	for t := range s.walk(to, true) {
+		if t.deadline.Before(from) {
+			continue
+		}
+		tasks = append(tasks, copyTask(t))
+	}
+
+	return tasks
+}
+
+// SchedulerSnapshot is a point-in-time dump of a Scheduler for debugging.
+type SchedulerSnapshot struct {
+	Taken       time.Time           `json:"taken"`
+	Ready       []*Task             `json:"ready"`
+	Blocked     map[string][]string `json:"blocked,omitempty"`
+	InFlight    []string            `json:"in_flight,omitempty"`
+	DeadLetters []string            `json:"dead_letters,omitempty"`
+}
+
+// Snapshot copies out everything the scheduler holds: ready tasks in
+// deadline order, blocked tasks with their prerequisites, and the IDs in
+// flight or dead-lettered.
+func (s *Scheduler) Snapshot() SchedulerSnapshot {
+
+	snap := SchedulerSnapshot{
+		Taken:   s.clock.Now(),
+		Ready:   make([]*Task, 0, s.ready.Len()),
+		Blocked: s.Blocked(),
+	}
+
+	for t := range s.All() {
+		snap.Ready = append(snap.Ready, t)
+	}
+
+	for id := range s.inflight {
+		snap.InFlight = append(snap.InFlight, id)
+	}
+	if s.leases != nil {
+		for id := range s.leases.byID {
+			snap.InFlight = append(snap.InFlight, id)
+		}
+	}
+	sort.Strings(snap.InFlight)
+
+	for id := range s.dead {
+		snap.DeadLetters = append(snap.DeadLetters, id)
+	}
+	sort.Strings(snap.DeadLetters)
+
+	return snap
+}
@@ -0,0 +1,172 @@
+package ds
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestGetReturnsCopy(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("job", now, WithLabel("team", "ops"), WithPriority(2))
+
+	got, ok := s.Get("job")
+	if !ok {
+		t.Fatal("Expected job to be found")
+	}
+	if !got.Deadline().Equal(now) || got.Priority() != 2 {
+		t.Errorf("Expected deadline %v priority 2, got %v %d", now, got.Deadline(), got.Priority())
+	}
+
+	got.Labels["team"] = "changed"
+	got.deadline = now.Add(time.Hour)
+
+	if s.byID["job"].Labels["team"] != "ops" || !s.byID["job"].deadline.Equal(now) {
+		t.Error("Expected changes to the copy not to reach the scheduler")
+	}
+
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Expected missing ID not to be found")
+	}
+}
+
+func TestLenAndNextDeadline(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	if _, ok := s.NextDeadline(); ok {
+		t.Error("Expected no next deadline for an empty scheduler")
+	}
+
+	s.AddOrUpdate("a", now.Add(2*time.Second))
+	s.AddOrUpdate("b", now.Add(time.Second))
+	s.AddOrUpdate("c", now)
+	s.AddDependency("c", "a")
+
+	if s.Len() != 3 {
+		t.Errorf("Expected Len 3 including the blocked task, got %d", s.Len())
+	}
+
+	next, ok := s.NextDeadline()
+	if !ok || !next.Equal(now.Add(time.Second)) {
+		t.Errorf("Expected next deadline %v, got %v", now.Add(time.Second), next)
+	}
+}
+
+func TestAllIteratesInOrderWithoutPopping(t *testing.T) {
+	s := CreateScheduler()
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for i := 0; i < 200; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%d", i), epoch.Add(time.Duration(rng.IntN(1000))*time.Second), WithPriority(rng.IntN(3)))
+	}
+
+	var seen []*Task
+	for task := range s.All() {
+		seen = append(seen, task)
+	}
+
+	if len(seen) != 200 || s.Len() != 200 {
+		t.Fatalf("Expected 200 tasks seen and still queued, got %d and %d", len(seen), s.Len())
+	}
+	if !sort.SliceIsSorted(seen, func(i, j int) bool { return seen[i].before(seen[j]) }) {
+		t.Error("Expected tasks in deadline order")
+	}
+
+	// Stopping early is fine
+	n := 0
+	for range s.All() {
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Errorf("Expected to stop after 5, got %d", n)
+	}
+
+	// Ties on deadline and priority are common here, and still come out
+	// the same way
+	popped := s.PopDue(epoch.Add(time.Hour))
+	for i, task := range popped {
+		if task.ID != seen[i].ID {
+			t.Fatalf("Expected iteration to match PopDue at %d: %s vs %s", i, seen[i].ID, task.ID)
+		}
+	}
+}
+
+func TestRangeMatchesPopDueOnTies(t *testing.T) {
+	s := CreateScheduler()
+
+	for i := 0; i < 12; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%02d", i), epoch)
+	}
+
+	ranged := ids(s.Range(epoch, epoch))
+	if popped := ids(s.PopDue(epoch)); !slices.Equal(ranged, popped) {
+		t.Errorf("Expected Range %v to match PopDue %v", ranged, popped)
+	}
+}
+
+func TestRange(t *testing.T) {
+	s := CreateScheduler()
+
+	for i := 0; i < 10; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%d", i), epoch.Add(time.Duration(i)*time.Minute))
+	}
+
+	got := ids(s.Range(epoch.Add(3*time.Minute), epoch.Add(6*time.Minute)))
+	if !slices.Equal(got, []string{"t3", "t4", "t5", "t6"}) {
+		t.Errorf("Expected [t3 t4 t5 t6], got %v", got)
+	}
+
+	if got := s.Range(epoch.Add(time.Hour), epoch.Add(2*time.Hour)); len(got) != 0 {
+		t.Errorf("Expected an empty range, got %v", ids(got))
+	}
+	if s.Len() != 10 {
+		t.Errorf("Expected Range not to remove tasks, got %d left", s.Len())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+	s.AddOrUpdate("ready", epoch.Add(time.Minute))
+	s.AddOrUpdate("blocked", epoch)
+	s.AddDependency("blocked", "ready")
+	s.AddOrUpdate("running", epoch)
+	s.AddOrUpdate("failing", epoch)
+
+	s.PopDueNow()
+	s.Nack("failing", nil)
+
+	snap := s.Snapshot()
+
+	if !snap.Taken.Equal(epoch) {
+		t.Errorf("Expected snapshot time %v, got %v", epoch, snap.Taken)
+	}
+	if got := ids(snap.Ready); !slices.Equal(got, []string{"ready"}) {
+		t.Errorf("Expected ready [ready], got %v", got)
+	}
+	if got := snap.Blocked["blocked"]; !slices.Equal(got, []string{"ready"}) {
+		t.Errorf("Expected blocked on [ready], got %v", got)
+	}
+	if !slices.Equal(snap.InFlight, []string{"running"}) {
+		t.Errorf("Expected in flight [running], got %v", snap.InFlight)
+	}
+	if !slices.Equal(snap.DeadLetters, []string{"failing"}) {
+		t.Errorf("Expected dead letters [failing], got %v", snap.DeadLetters)
+	}
+
+	if _, err := json.Marshal(snap); err != nil {
+		t.Errorf("Expected the snapshot to marshal, got %v", err)
+	}
+}
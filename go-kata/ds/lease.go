@@ -0,0 +1,93 @@
+package ds
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrLeaseExpired = errors.New("Lease expired!!")
+
+type lease struct {
+	task   *Task
+	worker string
+}
+
+// Claim pops the tasks due at now for workerID and leases them for d. A
+// leased task is not lost when its worker dies: unless it is Completed,
+// Acked or Nacked before the lease runs out, it goes back on the heap and
+// will be handed out again, so every task runs at least once. A returned
+// lease counts as a failed attempt under the retry policy, if one is set.
+func (s *Scheduler) Claim(workerID string, now time.Time, d time.Duration) []*Task {
+	return s.ClaimN(workerID, now, d, 0)
+}
+
+// ClaimN is Claim capped at limit tasks; limit <= 0 means no cap.
+func (s *Scheduler) ClaimN(workerID string, now time.Time, d time.Duration, limit int) []*Task {
+
+	s.ReclaimExpired(now)
+
+	if s.leases == nil {
+		s.leases = CreateSchedulerWithClock(s.clock)
+	}
+
+	due := s.PopDueN(now, limit)
+
+	for _, t := range due {
+		// The lease supersedes plain in-flight tracking
+		delete(s.inflight, t.ID)
+		s.leases.AddOrUpdate(t.ID, now.Add(d), WithPayload(&lease{task: t, worker: workerID}))
+	}
+
+	return due
+}
+
+// RenewLease extends workerID's lease on ID to d from now. It fails if the
+// lease has expired or belongs to another worker.
+func (s *Scheduler) RenewLease(ID, workerID string, now time.Time, d time.Duration) bool {
+
+	if s.leases == nil {
+		return false
+	}
+
+	l, ok := s.leases.byID[ID]
+	if !ok || l.Payload.(*lease).worker != workerID || !now.Before(l.deadline) {
+		return false
+	}
+
+	s.leases.AddOrUpdate(ID, now.Add(d))
+
+	return true
+}
+
+// Lease reports who holds the lease on ID and when it expires.
+func (s *Scheduler) Lease(ID string) (workerID string, expires time.Time, ok bool) {
+
+	if s.leases == nil {
+		return "", time.Time{}, false
+	}
+
+	l, ok := s.leases.byID[ID]
+	if !ok {
+		return "", time.Time{}, false
+	}
+
+	return l.Payload.(*lease).worker, l.deadline, true
+}
+
+// ReclaimExpired returns every task whose lease ran out at or before now to
+// the scheduler, and returns their IDs.
+func (s *Scheduler) ReclaimExpired(now time.Time) []string {
+
+	if s.leases == nil {
+		return nil
+	}
+
+	var reclaimed []string
+	for _, l := range s.leases.PopDue(now) {
+		t := l.Payload.(*lease).task
+		s.fail(t, ErrLeaseExpired)
+		reclaimed = append(reclaimed, t.ID)
+	}
+
+	return reclaimed
+}
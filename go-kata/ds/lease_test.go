@@ -0,0 +1,226 @@
+package ds
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClaimAndAck(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.AddOrUpdate("b", epoch.Add(time.Hour))
+
+	claimed := s.Claim("w1", epoch, time.Minute)
+	if got := ids(claimed); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("Expected [a], got %v", got)
+	}
+
+	worker, expires, ok := s.Lease("a")
+	if !ok || worker != "w1" || !expires.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Expected w1 to hold a until +1m, got %q %v %v", worker, expires, ok)
+	}
+	if snap := s.Snapshot(); len(snap.InFlight) != 1 || snap.InFlight[0] != "a" {
+		t.Errorf("Expected a in flight, got %v", snap.InFlight)
+	}
+
+	if !s.Ack("a") {
+		t.Fatal("Expected Ack to release the lease")
+	}
+
+	if _, _, ok := s.Lease("a"); ok {
+		t.Error("Expected the lease to be released")
+	}
+	clock.Advance(time.Hour)
+	if due := s.Claim("w1", clock.Now(), time.Minute); len(due) != 1 || due[0].ID != "b" {
+		t.Errorf("Expected only b once a was acknowledged, got %v", ids(due))
+	}
+}
+
+func TestCompleteAcknowledgesLease(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.AddOrUpdate("after-a", epoch)
+	s.AddDependency("after-a", "a")
+	s.Claim("w1", epoch, time.Minute)
+
+	if !s.Complete("a") {
+		t.Fatal("Expected the leased task to complete")
+	}
+	if _, _, ok := s.Lease("a"); ok {
+		t.Error("Expected Complete to release the lease")
+	}
+
+	clock.Advance(time.Hour)
+	if due := s.PopDueNow(); len(due) != 1 || due[0].ID != "after-a" {
+		t.Errorf("Expected only after-a, and a not handed out again, got %v", ids(due))
+	}
+}
+
+func TestExpiredLeaseIsReclaimed(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.Claim("w1", epoch, time.Minute)
+
+	clock.Advance(30 * time.Second)
+	if due := s.Claim("w2", clock.Now(), time.Minute); len(due) != 0 {
+		t.Fatalf("Expected nothing while the lease holds, got %v", ids(due))
+	}
+
+	// w1 died; once its lease runs out w2 gets the task
+	clock.Advance(30 * time.Second)
+	due := s.Claim("w2", clock.Now(), time.Minute)
+	if len(due) != 1 || due[0].ID != "a" {
+		t.Fatalf("Expected a to be reclaimed, got %v", ids(due))
+	}
+	if due[0].Attempts() != 1 || !errors.Is(due[0].LastError(), ErrLeaseExpired) {
+		t.Errorf("Expected one expired attempt, got %d and %v", due[0].Attempts(), due[0].LastError())
+	}
+	if worker, _, _ := s.Lease("a"); worker != "w2" {
+		t.Errorf("Expected w2 to hold the lease, got %q", worker)
+	}
+
+	// Acknowledging the stale claim is harmless, w2's lease is what counts
+	if !s.Ack("a") {
+		t.Error("Expected Ack to release the lease")
+	}
+	if s.Ack("a") {
+		t.Error("Expected a second Ack to fail")
+	}
+}
+
+func TestReclaimExpiredWithPlainPop(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.Claim("w1", epoch, time.Minute)
+
+	clock.Advance(time.Minute)
+	if due := s.PopDueNow(); len(due) != 1 || due[0].ID != "a" {
+		t.Errorf("Expected PopDue to see the expired lease, got %v", ids(due))
+	}
+}
+
+func TestPopAheadKeepsLiveLease(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.AddOrUpdate("b", epoch.Add(time.Minute))
+	s.Claim("w1", epoch, time.Minute)
+
+	// Popping ahead of the clock does not move lease expiry along with it
+	if due := s.PopDue(epoch.Add(time.Hour)); len(due) != 1 || due[0].ID != "b" {
+		t.Errorf("Expected only b, got %v", ids(due))
+	}
+	if worker, _, ok := s.Lease("a"); !ok || worker != "w1" {
+		t.Errorf("Expected w1 to still hold a, got %q %v", worker, ok)
+	}
+}
+
+func TestRenewLease(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch)
+	s.Claim("w1", epoch, time.Minute)
+
+	if s.RenewLease("a", "w2", epoch.Add(30*time.Second), time.Minute) {
+		t.Error("Expected another worker not to renew the lease")
+	}
+	if !s.RenewLease("a", "w1", epoch.Add(30*time.Second), time.Minute) {
+		t.Fatal("Expected the holder to renew the lease")
+	}
+
+	clock.Advance(time.Minute)
+	if due := s.Claim("w2", clock.Now(), time.Minute); len(due) != 0 {
+		t.Errorf("Expected the renewed lease to hold, got %v", ids(due))
+	}
+
+	clock.Advance(30 * time.Second)
+	if s.RenewLease("a", "w1", clock.Now(), time.Minute) {
+		t.Error("Expected an expired lease not to be renewed")
+	}
+	if s.RenewLease("missing", "w1", clock.Now(), time.Minute) {
+		t.Error("Expected no lease on an unknown ID")
+	}
+}
+
+func TestLeaseExpiryUsesRetryPolicy(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Minute, Multiplier: 2})
+
+	s.AddOrUpdate("a", epoch)
+	s.Claim("w1", epoch, time.Second)
+
+	clock.Advance(time.Second)
+	s.ReclaimExpired(clock.Now())
+
+	task, ok := s.Get("a")
+	if !ok || !task.Deadline().Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("Expected a to back off a minute, got %v", task)
+	}
+
+	clock.Advance(time.Minute)
+	s.Claim("w1", clock.Now(), time.Second)
+
+	// A Nack during the lease counts like an expiry
+	if retried, err := s.Nack("a", errors.New("boom")); err != nil || retried {
+		t.Errorf("Expected a to be dead-lettered, got %v, %v", retried, err)
+	}
+	if _, ok := s.DeadLetter("a"); !ok {
+		t.Error("Expected a in the dead letters")
+	}
+}
+
+func TestReclaimKeepsRequeuedTask(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdate("a", epoch, WithPriority(1))
+	s.Claim("w1", epoch, time.Minute)
+
+	s.AddOrUpdate("a", epoch.Add(time.Hour), WithPriority(2))
+
+	clock.Advance(time.Minute)
+	if reclaimed := s.ReclaimExpired(clock.Now()); len(reclaimed) != 1 {
+		t.Fatalf("Expected one lease to expire, got %v", reclaimed)
+	}
+
+	if task, _ := s.Get("a"); task.Priority() != 2 || !task.Deadline().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Expected the newer task to win, got priority %d at %v", task.Priority(), task.Deadline())
+	}
+}
+
+func TestClaimIsAtLeastOnce(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		s.AddOrUpdate(id, epoch)
+	}
+
+	seen := map[string]int{}
+	for round := 0; round < 4; round++ {
+		for _, task := range s.ClaimN("w", clock.Now(), time.Minute, 2) {
+			seen[task.ID]++
+			// Workers only ever finish b and d
+			if task.ID == "b" || task.ID == "d" {
+				s.Ack(task.ID)
+			}
+		}
+		clock.Advance(time.Minute)
+	}
+
+	if seen["b"] != 1 || seen["d"] != 1 || seen["a"] < 2 || seen["c"] < 2 {
+		t.Errorf("Expected b and d once and a and c retried, got %v", seen)
+	}
+}
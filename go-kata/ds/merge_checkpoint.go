@@ -0,0 +1,180 @@
+package ds
+
+import (
+	"bufio"
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SeekableStream is a Stream that can report how far it has been read and
+// jump back to such a position, which is what makes a merge resumable.
+type SeekableStream interface {
+	Stream
+	Offset() int64
+	SeekTo(offset int64) error
+}
+
+// PendingValue is a value that has been read from a stream but is still
+// sitting in the merge heap.
+type PendingValue struct {
+	Stream int `json:"stream"`
+	Val    int `json:"val"`
+}
+
+// Checkpoint captures a StreamMerger mid-merge: the read offset of every
+// stream plus the values already pulled into the heap.
+type Checkpoint struct {
+	Offsets []int64        `json:"offsets"`
+	Pending []PendingValue `json:"pending"`
+}
+
+func (m *StreamMerger) Checkpoint() (Checkpoint, error) {
+
+	cp := Checkpoint{
+		Offsets: make([]int64, len(m.streams)),
+		Pending: make([]PendingValue, 0, len(m.nodes)),
+	}
+
+	for id, stream := range m.streams {
+		seekable, ok := stream.(SeekableStream)
+		if !ok {
+			return Checkpoint{}, fmt.Errorf("Stream %d is not seekable!!", id)
+		}
+		cp.Offsets[id] = seekable.Offset()
+	}
+
+	for _, node := range m.nodes {
+		cp.Pending = append(cp.Pending, PendingValue{Stream: node.id, Val: node.val})
+	}
+
+	return cp, nil
+}
+
+// ResumeMerger rebuilds a merger from a checkpoint taken over the same
+// streams, in the same order. The merged output continues exactly where the
+// checkpointed merger left off.
+func ResumeMerger(streams []SeekableStream, cp Checkpoint) (*StreamMerger, error) {
+
+	if len(cp.Offsets) != len(streams) {
+		return nil, fmt.Errorf("Checkpoint has %d offsets for %d streams!!", len(cp.Offsets), len(streams))
+	}
+
+	merger := StreamMerger{streams: make([]Stream, len(streams))}
+
+	for id, stream := range streams {
+		if err := stream.SeekTo(cp.Offsets[id]); err != nil {
+			return nil, fmt.Errorf("Seeking stream %d: %w", id, err)
+		}
+		merger.streams[id] = stream
+	}
+
+	for _, p := range cp.Pending {
+		if p.Stream < 0 || p.Stream >= len(streams) {
+			return nil, fmt.Errorf("Pending value refers to unknown stream %d!!", p.Stream)
+		}
+		merger.nodes = append(merger.nodes, HeapNode{val: p.Val, stream: streams[p.Stream], id: p.Stream})
+	}
+
+	heap.Init(&merger.nodes)
+
+	return &merger, nil
+}
+
+// SliceStream is an in-memory SeekableStream whose offset is the index of
+// the next value.
+type SliceStream struct {
+	values []int
+	pos    int
+}
+
+func NewSliceStream(values []int) *SliceStream {
+	return &SliceStream{values: values}
+}
+
+func (s *SliceStream) Next() (int, bool) {
+	if s.pos >= len(s.values) {
+		return 0, false
+	}
+
+	v := s.values[s.pos]
+	s.pos++
+
+	return v, true
+}
+
+func (s *SliceStream) Offset() int64 { return int64(s.pos) }
+
+func (s *SliceStream) SeekTo(offset int64) error {
+	if offset < 0 || offset > int64(len(s.values)) {
+		return errors.New("Offset out of range!!")
+	}
+
+	s.pos = int(offset)
+	return nil
+}
+
+// LineStream reads one integer per line from a file (or any io.ReadSeeker).
+// Its offset is the byte position of the next unread line. Blank lines are
+// skipped; a line that is not an integer ends the stream and is reported
+// by Err.
+type LineStream struct {
+	rs     io.ReadSeeker
+	r      *bufio.Reader
+	offset int64
+	err    error
+}
+
+func NewLineStream(rs io.ReadSeeker) *LineStream {
+	return &LineStream{rs: rs, r: bufio.NewReader(rs)}
+}
+
+func (s *LineStream) Next() (int, bool) {
+
+	for s.err == nil {
+		line, err := s.r.ReadString('\n')
+		s.offset += int64(len(line))
+
+		if err != nil && err != io.EOF {
+			s.err = err
+			return 0, false
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if err == io.EOF {
+				return 0, false
+			}
+			continue
+		}
+
+		v, convErr := strconv.Atoi(trimmed)
+		if convErr != nil {
+			s.err = convErr
+			return 0, false
+		}
+
+		return v, true
+	}
+
+	return 0, false
+}
+
+func (s *LineStream) Offset() int64 { return s.offset }
+
+func (s *LineStream) SeekTo(offset int64) error {
+	if _, err := s.rs.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	s.r.Reset(s.rs)
+	s.offset = offset
+	s.err = nil
+
+	return nil
+}
+
+func (s *LineStream) Err() error { return s.err }
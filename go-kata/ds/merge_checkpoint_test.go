@@ -0,0 +1,234 @@
+package ds
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func drain(m *StreamMerger) []int {
+	out := make([]int, 0)
+	for {
+		v, ok := m.Next()
+		if !ok {
+			return out
+		}
+		out = append(out, v)
+	}
+}
+
+func TestSliceStream(t *testing.T) {
+	t.Run("SeekTo rewinds and fast-forwards", func(t *testing.T) {
+		s := NewSliceStream([]int{1, 2, 3})
+
+		s.Next()
+		s.Next()
+		if s.Offset() != 2 {
+			t.Errorf("Expected offset 2, got %d", s.Offset())
+		}
+
+		if err := s.SeekTo(1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		val, ok := s.Next()
+		if !ok || val != 2 {
+			t.Errorf("Expected (2, true), got (%d, %t)", val, ok)
+		}
+	})
+
+	t.Run("SeekTo out of range", func(t *testing.T) {
+		s := NewSliceStream([]int{1, 2, 3})
+
+		if err := s.SeekTo(4); err == nil {
+			t.Error("Expected error when seeking past the end")
+		}
+		if err := s.SeekTo(-1); err == nil {
+			t.Error("Expected error when seeking before the start")
+		}
+	})
+}
+
+func TestLineStream(t *testing.T) {
+	t.Run("Reads integers and skips blank lines", func(t *testing.T) {
+		s := NewLineStream(strings.NewReader("1\n\n-2\n30"))
+
+		var got []int
+		for {
+			v, ok := s.Next()
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+
+		if !reflect.DeepEqual(got, []int{1, -2, 30}) {
+			t.Errorf("Expected [1 -2 30], got %v", got)
+		}
+		if s.Err() != nil {
+			t.Errorf("Unexpected error: %v", s.Err())
+		}
+	})
+
+	t.Run("SeekTo a line offset", func(t *testing.T) {
+		s := NewLineStream(strings.NewReader("10\n20\n30\n"))
+
+		s.Next()
+		offset := s.Offset()
+		s.Next()
+		s.Next()
+
+		if err := s.SeekTo(offset); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		val, ok := s.Next()
+		if !ok || val != 20 {
+			t.Errorf("Expected (20, true), got (%d, %t)", val, ok)
+		}
+	})
+
+	t.Run("Malformed line ends the stream", func(t *testing.T) {
+		s := NewLineStream(strings.NewReader("1\nabc\n3\n"))
+
+		s.Next()
+		if _, ok := s.Next(); ok {
+			t.Error("Expected Next() to return false on malformed line")
+		}
+		if s.Err() == nil {
+			t.Error("Expected Err() to report the malformed line")
+		}
+	})
+}
+
+func TestCheckpointResume(t *testing.T) {
+	newStreams := func() []SeekableStream {
+		return []SeekableStream{
+			NewSliceStream([]int{1, 4, 7, 10}),
+			NewSliceStream([]int{2, 2, 5, 8}),
+			NewSliceStream([]int{3, 6}),
+		}
+	}
+
+	asStreams := func(ss []SeekableStream) []Stream {
+		out := make([]Stream, len(ss))
+		for i, s := range ss {
+			out[i] = s
+		}
+		return out
+	}
+
+	expected := drain(CreateMerger(asStreams(newStreams())))
+
+	for stop := 0; stop <= len(expected); stop++ {
+		merger := CreateMerger(asStreams(newStreams()))
+
+		var got []int
+		for i := 0; i < stop; i++ {
+			v, _ := merger.Next()
+			got = append(got, v)
+		}
+
+		cp, err := merger.Checkpoint()
+		if err != nil {
+			t.Fatalf("Checkpoint after %d values: %v", stop, err)
+		}
+
+		// Round-trip through JSON as a real restart would
+		data, err := json.Marshal(cp)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var restored Checkpoint
+		if err := json.Unmarshal(data, &restored); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+
+		resumed, err := ResumeMerger(newStreams(), restored)
+		if err != nil {
+			t.Fatalf("ResumeMerger after %d values: %v", stop, err)
+		}
+
+		got = append(got, drain(resumed)...)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Stop at %d: expected %v, got %v", stop, expected, got)
+		}
+	}
+}
+
+func TestCheckpointResumeFiles(t *testing.T) {
+	dir := t.TempDir()
+	contents := []string{"1\n5\n9\n", "2\n3\n8\n13\n", "4\n"}
+
+	open := func() []SeekableStream {
+		streams := make([]SeekableStream, 0, len(contents))
+		for i, c := range contents {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			if err := os.WriteFile(path, []byte(c), 0o644); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			t.Cleanup(func() { f.Close() })
+			streams = append(streams, NewLineStream(f))
+		}
+		return streams
+	}
+
+	first := open()
+	merger := CreateMerger([]Stream{first[0], first[1], first[2]})
+
+	got := make([]int, 0)
+	for i := 0; i < 4; i++ {
+		v, _ := merger.Next()
+		got = append(got, v)
+	}
+
+	cp, err := merger.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+
+	resumed, err := ResumeMerger(open(), cp)
+	if err != nil {
+		t.Fatalf("ResumeMerger: %v", err)
+	}
+	got = append(got, drain(resumed)...)
+
+	expected := []int{1, 2, 3, 4, 5, 8, 9, 13}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestCheckpointErrors(t *testing.T) {
+	t.Run("Checkpoint with non-seekable stream", func(t *testing.T) {
+		merger := CreateMerger([]Stream{NewMockStream([]int{1, 2})})
+
+		if _, err := merger.Checkpoint(); err == nil {
+			t.Error("Expected error for non-seekable stream")
+		}
+	})
+
+	t.Run("Resume with mismatched stream count", func(t *testing.T) {
+		cp := Checkpoint{Offsets: []int64{0, 0}}
+
+		if _, err := ResumeMerger([]SeekableStream{NewSliceStream(nil)}, cp); err == nil {
+			t.Error("Expected error for mismatched stream count")
+		}
+	})
+
+	t.Run("Resume with unknown pending stream", func(t *testing.T) {
+		cp := Checkpoint{
+			Offsets: []int64{0},
+			Pending: []PendingValue{{Stream: 3, Val: 1}},
+		}
+
+		if _, err := ResumeMerger([]SeekableStream{NewSliceStream(nil)}, cp); err == nil {
+			t.Error("Expected error for unknown pending stream")
+		}
+	})
+}
@@ -9,12 +9,14 @@ type Stream interface {
 type HeapNode struct {
 	val    int
 	stream Stream
+	id     int // position of stream in the merger, used for checkpoints
 }
 
 type StreamHeap []HeapNode
 
 type StreamMerger struct {
-	nodes StreamHeap
+	nodes   StreamHeap
+	streams []Stream
 }
 
 func (h StreamHeap) Len() int {
@@ -22,6 +24,9 @@ func (h StreamHeap) Len() int {
 }
 
 func (h StreamHeap) Less(i, j int) bool {
+	if h[i].val == h[j].val {
+		return h[i].id < h[j].id
+	}
 	return h[i].val < h[j].val
 }
 
@@ -44,14 +49,14 @@ func (h *StreamHeap) Pop() any {
 }
 
 func CreateMerger(streams []Stream) *StreamMerger {
-	merger := StreamMerger{}
+	merger := StreamMerger{streams: streams}
 
 	heap.Init(&merger.nodes)
 
-	for _, stream := range streams {
+	for id, stream := range streams {
 
 		if val, ok := stream.Next(); ok {
-			heap.Push(&merger.nodes, HeapNode{val: val, stream: stream})
+			heap.Push(&merger.nodes, HeapNode{val: val, stream: stream, id: id})
 		}
 	}
 
@@ -69,7 +74,7 @@ func (m *StreamMerger) Next() (int, bool) {
 	s := node.stream
 
 	if new_value, ok := s.Next(); ok {
-		heap.Push(&m.nodes, HeapNode{val: new_value, stream: s})
+		heap.Push(&m.nodes, HeapNode{val: new_value, stream: s, id: node.id})
 	}
 
 	return v, true
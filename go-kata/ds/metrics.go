@@ -0,0 +1,207 @@
+package ds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metrics receives Scheduler events. Lateness is how long after its
+// deadline a task was popped, by the scheduler's clock; tasks popped early
+// report zero. QueueDepth is called with the number of tasks held after
+// every change.
+type Metrics interface {
+	TaskAdded()
+	TaskUpdated()
+	TaskRemoved()
+	TaskPopped(lateness time.Duration)
+	QueueDepth(n int)
+}
+
+// SetMetrics sends the scheduler's events to m; nil turns them off.
+func (s *Scheduler) SetMetrics(m Metrics) {
+	s.metrics = m
+	if m != nil {
+		m.QueueDepth(s.Len())
+	}
+}
+
+func (s *Scheduler) reportDepth() {
+	if s.metrics != nil {
+		s.metrics.QueueDepth(s.Len())
+	}
+}
+
+// DefaultLatenessBuckets are the upper bounds of the lateness histogram,
+// from a millisecond to a minute.
+var DefaultLatenessBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	time.Minute,
+}
+
+// InMemoryMetrics counts events and keeps a lateness histogram. It is safe
+// for concurrent use, so it can be read while a scheduler is running.
+type InMemoryMetrics struct {
+	mu sync.Mutex
+
+	added   uint64
+	updated uint64
+	removed uint64
+	depth   int
+
+	bounds []time.Duration
+	counts []uint64 // counts[i] is lateness <= bounds[i]; the last is +Inf
+	sum    time.Duration
+	popped uint64
+}
+
+// NewInMemoryMetrics builds a histogram with the given bucket bounds, or
+// DefaultLatenessBuckets when none are given.
+func NewInMemoryMetrics(buckets ...time.Duration) *InMemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatenessBuckets
+	}
+
+	bounds := append([]time.Duration(nil), buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	return &InMemoryMetrics{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+func (m *InMemoryMetrics) TaskAdded() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.added++
+}
+
+func (m *InMemoryMetrics) TaskUpdated() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.updated++
+}
+
+func (m *InMemoryMetrics) TaskRemoved() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removed++
+}
+
+func (m *InMemoryMetrics) TaskPopped(lateness time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := sort.Search(len(m.bounds), func(i int) bool { return lateness <= m.bounds[i] })
+	m.counts[i]++
+	m.sum += lateness
+	m.popped++
+}
+
+func (m *InMemoryMetrics) QueueDepth(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depth = n
+}
+
+// HistogramBucket is a cumulative histogram bucket: Count observations were
+// at most UpperBound. The last bucket's bound is negative, meaning +Inf.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// MetricsSnapshot is a consistent copy of InMemoryMetrics.
+type MetricsSnapshot struct {
+	Added   uint64
+	Updated uint64
+	Removed uint64
+	Popped  uint64
+	Depth   int
+
+	Lateness    []HistogramBucket
+	LatenessSum time.Duration
+}
+
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := MetricsSnapshot{
+		Added:       m.added,
+		Updated:     m.updated,
+		Removed:     m.removed,
+		Popped:      m.popped,
+		Depth:       m.depth,
+		Lateness:    make([]HistogramBucket, 0, len(m.counts)),
+		LatenessSum: m.sum,
+	}
+
+	var cumulative uint64
+	for i, count := range m.counts {
+		cumulative += count
+		bound := time.Duration(-1)
+		if i < len(m.bounds) {
+			bound = m.bounds[i]
+		}
+		snap.Lateness = append(snap.Lateness, HistogramBucket{UpperBound: bound, Count: cumulative})
+	}
+
+	return snap
+}
+
+// WritePrometheus writes m in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer, m *InMemoryMetrics) error {
+
+	snap := m.Snapshot()
+
+	counters := []struct {
+		name, help string
+		value      uint64
+	}{
+		{"scheduler_tasks_added_total", "Tasks added to the scheduler.", snap.Added},
+		{"scheduler_tasks_updated_total", "Tasks rescheduled while queued.", snap.Updated},
+		{"scheduler_tasks_removed_total", "Tasks removed before running.", snap.Removed},
+	}
+
+	for _, c := range counters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP scheduler_queue_depth Tasks currently held.\n# TYPE scheduler_queue_depth gauge\nscheduler_queue_depth %d\n", snap.Depth); err != nil {
+		return err
+	}
+
+	const name = "scheduler_lateness_seconds"
+	if _, err := fmt.Fprintf(w, "# HELP %s Time from a task's deadline to when it was popped.\n# TYPE %s histogram\n", name, name); err != nil {
+		return err
+	}
+
+	for _, b := range snap.Lateness {
+		le := "+Inf"
+		if b.UpperBound >= 0 {
+			le = strconv.FormatFloat(b.UpperBound.Seconds(), 'g', -1, 64)
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, le, b.Count); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", name, strconv.FormatFloat(snap.LatenessSum.Seconds(), 'g', -1, 64), name, snap.Popped)
+
+	return err
+}
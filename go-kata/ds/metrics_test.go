@@ -0,0 +1,116 @@
+package ds
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedulerMetrics(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	m := NewInMemoryMetrics(time.Second, 10*time.Second)
+	s.SetMetrics(m)
+
+	s.AddOrUpdate("a", epoch)
+	s.AddOrUpdate("b", epoch.Add(5*time.Second))
+	s.AddOrUpdate("c", epoch.Add(30*time.Second))
+	s.AddOrUpdate("d", epoch.Add(time.Hour))
+	s.AddOrUpdate("b", epoch.Add(8*time.Second))
+	s.Remove("d")
+	s.Remove("missing")
+
+	snap := m.Snapshot()
+	if snap.Added != 4 || snap.Updated != 1 || snap.Removed != 1 || snap.Depth != 3 {
+		t.Fatalf("Expected 4 added, 1 updated, 1 removed, depth 3, got %+v", snap)
+	}
+
+	clock.Advance(8*time.Second + 500*time.Millisecond)
+	s.PopDueNow()
+
+	clock.Advance(time.Minute)
+	s.PopDueNow()
+
+	snap = m.Snapshot()
+	if snap.Popped != 3 || snap.Depth != 0 {
+		t.Errorf("Expected 3 popped and depth 0, got %+v", snap)
+	}
+
+	// a was 8.5s late, b 0.5s, c 38.5s
+	expected := []HistogramBucket{{time.Second, 1}, {10 * time.Second, 2}, {-1, 3}}
+	for i, b := range expected {
+		if snap.Lateness[i] != b {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, b, snap.Lateness[i])
+		}
+	}
+	if snap.LatenessSum != 47500*time.Millisecond {
+		t.Errorf("Expected lateness sum 47.5s, got %v", snap.LatenessSum)
+	}
+}
+
+func TestMetricsEarlyPopIsNotLate(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	m := NewInMemoryMetrics()
+	s.SetMetrics(m)
+
+	s.AddOrUpdate("a", epoch.Add(time.Minute))
+	s.PopDue(epoch.Add(time.Hour))
+
+	if snap := m.Snapshot(); snap.LatenessSum != 0 || snap.Lateness[0].Count != 1 {
+		t.Errorf("Expected an early pop to count as zero lateness, got %+v", snap)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	m := NewInMemoryMetrics(100*time.Millisecond, time.Second)
+
+	m.TaskAdded()
+	m.TaskAdded()
+	m.TaskUpdated()
+	m.QueueDepth(1)
+	m.TaskPopped(50 * time.Millisecond)
+	m.TaskPopped(2 * time.Second)
+
+	var out strings.Builder
+	if err := WritePrometheus(&out, m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP scheduler_tasks_added_total Tasks added to the scheduler.
+# TYPE scheduler_tasks_added_total counter
+scheduler_tasks_added_total 2
+# HELP scheduler_tasks_updated_total Tasks rescheduled while queued.
+# TYPE scheduler_tasks_updated_total counter
+scheduler_tasks_updated_total 1
+# HELP scheduler_tasks_removed_total Tasks removed before running.
+# TYPE scheduler_tasks_removed_total counter
+scheduler_tasks_removed_total 0
+# HELP scheduler_queue_depth Tasks currently held.
+# TYPE scheduler_queue_depth gauge
+scheduler_queue_depth 1
+# HELP scheduler_lateness_seconds Time from a task's deadline to when it was popped.
+# TYPE scheduler_lateness_seconds histogram
+scheduler_lateness_seconds_bucket{le="0.1"} 1
+scheduler_lateness_seconds_bucket{le="1"} 1
+scheduler_lateness_seconds_bucket{le="+Inf"} 2
+scheduler_lateness_seconds_sum 2.05
+scheduler_lateness_seconds_count 2
+`
+
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWritePrometheusReportsWriteErrors(t *testing.T) {
+	if err := WritePrometheus(failingWriter{}, NewInMemoryMetrics()); err == nil {
+		t.Error("Expected the write error to be returned")
+	}
+}
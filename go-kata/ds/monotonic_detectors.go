@@ -0,0 +1,298 @@
+package ds
+
+// monoQueue pairs each index with its value, so the streaming detectors do not
+// need to keep the whole input around. It grows as needed.
+type monoQueue struct {
+	idx *Deque
+	val *Deque
+}
+
+func newMonoQueue() monoQueue {
+	return monoQueue{idx: NewDeque(16), val: NewDeque(16)}
+}
+
+func (q monoQueue) empty() bool { return q.idx.Empty() }
+func (q monoQueue) len() int    { return q.idx.Len() }
+
+// at returns the i-th entry counting from the front.
+func (q monoQueue) at(i int) (int, int) {
+	return q.idx.get(q.idx.front + uint64(i)), q.val.get(q.val.front + uint64(i))
+}
+
+func (q monoQueue) pushBack(i, v int) {
+	if q.idx.Full() {
+		q.idx.grow()
+		q.val.grow()
+	}
+	q.idx.PushBack(i)
+	q.val.PushBack(v)
+}
+
+func (q monoQueue) back() (int, int) {
+	i, _ := q.idx.PeekBack()
+	v, _ := q.val.PeekBack()
+	return i, v
+}
+
+func (q monoQueue) front() (int, int) {
+	i, _ := q.idx.PeekFront()
+	v, _ := q.val.PeekFront()
+	return i, v
+}
+
+func (q monoQueue) popBack() (int, int) {
+	i, _ := q.idx.PopBack()
+	v, _ := q.val.PopBack()
+	return i, v
+}
+
+func (q monoQueue) popFront() {
+	q.idx.PopFront()
+	q.val.PopFront()
+}
+
+func (q monoQueue) indexes() []int {
+	out := make([]int, 0, q.len())
+	for i := 0; i < q.len(); i++ {
+		idx, _ := q.at(i)
+		out = append(out, idx)
+	}
+	return out
+}
+
+// NextFinder resolves, for every pushed value, the index of the next value
+// that is strictly greater (or smaller). Values still waiting for an answer
+// sit in a monotonic stack.
+type NextFinder struct {
+	n       int
+	stack   monoQueue
+	resolve func(old, new int) bool
+}
+
+func NewNextGreaterFinder() *NextFinder {
+	return &NextFinder{stack: newMonoQueue(), resolve: func(old, new int) bool { return new > old }}
+}
+
+func NewNextSmallerFinder() *NextFinder {
+	return &NextFinder{stack: newMonoQueue(), resolve: func(old, new int) bool { return new < old }}
+}
+
+// Push adds x and returns the indexes whose next greater (smaller) element is
+// x, most recent first.
+func (f *NextFinder) Push(x int) []int {
+	var resolved []int
+
+	for !f.stack.empty() {
+		_, old_value := f.stack.back()
+		if !f.resolve(old_value, x) {
+			break
+		}
+		back_idx, _ := f.stack.popBack()
+		resolved = append(resolved, back_idx)
+	}
+
+	f.stack.pushBack(f.n, x)
+	f.n++
+
+	return resolved
+}
+
+// Pending returns the indexes that have no next greater (smaller) element yet.
+func (f *NextFinder) Pending() []int {
+	return f.stack.indexes()
+}
+
+func nextIndexes(nums []int, f *NextFinder) []int {
+	out := make([]int, len(nums))
+	for i := range out {
+		out[i] = -1
+	}
+
+	for curr_idx, num := range nums {
+		for _, idx := range f.Push(num) {
+			out[idx] = curr_idx
+		}
+	}
+
+	return out
+}
+
+// NextGreater returns, for each element, the index of the next strictly
+// greater element, or -1 if there is none.
+func NextGreater(nums []int) []int {
+	return nextIndexes(nums, NewNextGreaterFinder())
+}
+
+// NextSmaller returns, for each element, the index of the next strictly
+// smaller element, or -1 if there is none.
+func NextSmaller(nums []int) []int {
+	return nextIndexes(nums, NewNextSmallerFinder())
+}
+
+// StockSpanner reports, for each day's price, how many consecutive days up to
+// and including today had a price less than or equal to today's.
+type StockSpanner struct {
+	n     int
+	stack monoQueue // strictly decreasing prices
+}
+
+func NewStockSpanner() *StockSpanner {
+	return &StockSpanner{stack: newMonoQueue()}
+}
+
+func (s *StockSpanner) Push(price int) int {
+	for !s.stack.empty() {
+		_, back_price := s.stack.back()
+		if back_price > price {
+			break
+		}
+		s.stack.popBack()
+	}
+
+	span := s.n + 1
+	if !s.stack.empty() {
+		prev_idx, _ := s.stack.back()
+		span = s.n - prev_idx
+	}
+
+	s.stack.pushBack(s.n, price)
+	s.n++
+
+	return span
+}
+
+func StockSpan(prices []int) []int {
+	s := NewStockSpanner()
+
+	out := make([]int, 0, len(prices))
+	for _, p := range prices {
+		out = append(out, s.Push(p))
+	}
+
+	return out
+}
+
+// HistogramMax tracks the largest rectangle in a histogram whose bars arrive
+// one at a time. The stack holds bars of increasing height, each paired with
+// the leftmost index its rectangle can extend to.
+type HistogramMax struct {
+	n     int
+	best  int
+	stack monoQueue
+}
+
+func NewHistogramMax() *HistogramMax {
+	return &HistogramMax{stack: newMonoQueue()}
+}
+
+func (h *HistogramMax) Push(height int) {
+	start := h.n
+
+	for !h.stack.empty() {
+		_, back_height := h.stack.back()
+		if back_height < height {
+			break
+		}
+		left, popped_height := h.stack.popBack()
+		h.best = max(h.best, popped_height*(h.n-left))
+		start = left
+	}
+
+	h.stack.pushBack(start, height)
+	h.n++
+}
+
+// Max returns the largest rectangle among the bars pushed so far. Bars still
+// on the stack extend to the current end; that costs O(stack) per call.
+func (h *HistogramMax) Max() int {
+	best := h.best
+	for i := 0; i < h.stack.len(); i++ {
+		left, height := h.stack.at(i)
+		best = max(best, height*(h.n-left))
+	}
+	return best
+}
+
+func LargestRectangle(heights []int) int {
+	h := NewHistogramMax()
+	for _, height := range heights {
+		h.Push(height)
+	}
+	return h.Max()
+}
+
+// LimitWindow tracks the longest contiguous run of pushed values whose
+// max-min is at most limit, using the RollingMinMax deques over a window
+// whose left edge only moves when the limit is exceeded.
+type LimitWindow struct {
+	limit int
+	n     int
+	left  int
+
+	bestStart int
+	bestLen   int
+
+	maxQ monoQueue
+	minQ monoQueue
+}
+
+func NewLimitWindow(limit int) *LimitWindow {
+	return &LimitWindow{limit: limit, maxQ: newMonoQueue(), minQ: newMonoQueue()}
+}
+
+// Push adds x and returns the start index and length of the longest run seen
+// so far (the earliest one on ties).
+func (w *LimitWindow) Push(x int) (start, length int) {
+
+	for !w.maxQ.empty() {
+		if _, v := w.maxQ.back(); v >= x {
+			break
+		}
+		w.maxQ.popBack()
+	}
+	w.maxQ.pushBack(w.n, x)
+
+	for !w.minQ.empty() {
+		if _, v := w.minQ.back(); v <= x {
+			break
+		}
+		w.minQ.popBack()
+	}
+	w.minQ.pushBack(w.n, x)
+
+	// A negative limit can empty both queues, hence the bound on left
+	for w.left <= w.n {
+		_, maxV := w.maxQ.front()
+		_, minV := w.minQ.front()
+		if maxV-minV <= w.limit {
+			break
+		}
+
+		w.left++
+		if idx, _ := w.maxQ.front(); idx < w.left {
+			w.maxQ.popFront()
+		}
+		if idx, _ := w.minQ.front(); idx < w.left {
+			w.minQ.popFront()
+		}
+	}
+
+	if w.n-w.left+1 > w.bestLen {
+		w.bestStart = w.left
+		w.bestLen = w.n - w.left + 1
+	}
+
+	w.n++
+
+	return w.bestStart, w.bestLen
+}
+
+// LongestWithinLimit returns the start and length of the longest subarray
+// whose max-min is at most limit.
+func LongestWithinLimit(nums []int, limit int) (start, length int) {
+	w := NewLimitWindow(limit)
+	for _, num := range nums {
+		start, length = w.Push(num)
+	}
+	return start, length
+}
@@ -0,0 +1,174 @@
+package ds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNextGreaterSmaller(t *testing.T) {
+	tests := []struct {
+		name            string
+		nums            []int
+		expectedGreater []int
+		expectedSmaller []int
+	}{
+		{
+			name:            "mixed sequence",
+			nums:            []int{2, 1, 2, 4, 3},
+			expectedGreater: []int{3, 2, 3, -1, -1},
+			expectedSmaller: []int{1, -1, -1, 4, -1},
+		},
+		{
+			name:            "increasing sequence",
+			nums:            []int{1, 2, 3},
+			expectedGreater: []int{1, 2, -1},
+			expectedSmaller: []int{-1, -1, -1},
+		},
+		{
+			name:            "duplicate elements",
+			nums:            []int{5, 5, 5},
+			expectedGreater: []int{-1, -1, -1},
+			expectedSmaller: []int{-1, -1, -1},
+		},
+		{
+			name:            "empty array",
+			nums:            []int{},
+			expectedGreater: []int{},
+			expectedSmaller: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextGreater(tt.nums); !reflect.DeepEqual(got, tt.expectedGreater) {
+				t.Errorf("NextGreater(%v) = %v, want %v", tt.nums, got, tt.expectedGreater)
+			}
+			if got := NextSmaller(tt.nums); !reflect.DeepEqual(got, tt.expectedSmaller) {
+				t.Errorf("NextSmaller(%v) = %v, want %v", tt.nums, got, tt.expectedSmaller)
+			}
+		})
+	}
+}
+
+func TestNextFinder_Streaming(t *testing.T) {
+	f := NewNextGreaterFinder()
+
+	f.Push(5)
+	f.Push(3)
+	f.Push(1)
+
+	if got := f.Push(4); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("Push(4) resolved %v, want [2 1]", got)
+	}
+	if got := f.Pending(); !reflect.DeepEqual(got, []int{0, 3}) {
+		t.Errorf("Pending() = %v, want [0 3]", got)
+	}
+}
+
+func TestStockSpan(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []int
+		expected []int
+	}{
+		{
+			name:     "classic example",
+			prices:   []int{100, 80, 60, 70, 60, 75, 85},
+			expected: []int{1, 1, 1, 2, 1, 4, 6},
+		},
+		{
+			name:     "equal prices extend the span",
+			prices:   []int{10, 10, 10},
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     "empty array",
+			prices:   []int{},
+			expected: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StockSpan(tt.prices); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("StockSpan(%v) = %v, want %v", tt.prices, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLargestRectangle(t *testing.T) {
+	tests := []struct {
+		name     string
+		heights  []int
+		expected int
+	}{
+		{name: "classic example", heights: []int{2, 1, 5, 6, 2, 3}, expected: 10},
+		{name: "increasing bars", heights: []int{1, 2, 3, 4, 5}, expected: 9},
+		{name: "equal bars", heights: []int{3, 3, 3}, expected: 9},
+		{name: "zero bar splits", heights: []int{4, 0, 4}, expected: 4},
+		{name: "empty histogram", heights: []int{}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LargestRectangle(tt.heights); got != tt.expected {
+				t.Errorf("LargestRectangle(%v) = %d, want %d", tt.heights, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHistogramMax_Streaming(t *testing.T) {
+	h := NewHistogramMax()
+
+	expected := []int{2, 2, 5, 10, 10, 10}
+	for i, height := range []int{2, 1, 5, 6, 2, 3} {
+		h.Push(height)
+		if got := h.Max(); got != expected[i] {
+			t.Errorf("After %d bars: Max() = %d, want %d", i+1, got, expected[i])
+		}
+	}
+}
+
+func TestLongestWithinLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		nums          []int
+		limit         int
+		expectedStart int
+		expectedLen   int
+	}{
+		{name: "classic example", nums: []int{8, 2, 4, 7}, limit: 4, expectedStart: 1, expectedLen: 2},
+		{name: "longer run", nums: []int{10, 1, 2, 4, 7, 2}, limit: 5, expectedStart: 2, expectedLen: 4},
+		{name: "limit zero", nums: []int{4, 2, 2, 2, 4, 4, 2, 2}, limit: 0, expectedStart: 1, expectedLen: 3},
+		{name: "everything fits", nums: []int{1, 2, 3}, limit: 10, expectedStart: 0, expectedLen: 3},
+		{name: "negative limit", nums: []int{1, 2, 3}, limit: -1, expectedStart: 0, expectedLen: 0},
+		{name: "empty array", nums: []int{}, limit: 3, expectedStart: 0, expectedLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, length := LongestWithinLimit(tt.nums, tt.limit)
+			if start != tt.expectedStart || length != tt.expectedLen {
+				t.Errorf("LongestWithinLimit(%v, %d) = (%d, %d), want (%d, %d)",
+					tt.nums, tt.limit, start, length, tt.expectedStart, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestMonoQueueGrows(t *testing.T) {
+	// More than the initial capacity of the underlying deques
+	nums := make([]int, 100)
+	for i := range nums {
+		nums[i] = 100 - i
+	}
+
+	got := NextGreater(nums)
+	for i, idx := range got {
+		if idx != -1 {
+			t.Fatalf("NextGreater of decreasing input at %d = %d, want -1", i, idx)
+		}
+	}
+}
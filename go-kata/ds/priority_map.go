@@ -0,0 +1,192 @@
+package ds
+
+import (
+	"container/heap"
+	"iter"
+)
+
+// PriorityMap is a heap addressable by key: every key holds one priority,
+// the lowest of which is popped first by less, and any key can be read,
+// reprioritised or deleted in O(log n) through the position each entry
+// keeps of itself in the heap.
+type PriorityMap[K comparable, P any] struct {
+	h     pmHeap[K, P]
+	byKey map[K]*pmEntry[K, P]
+}
+
+type pmEntry[K comparable, P any] struct {
+	key      K
+	priority P
+	index    int
+}
+
+type pmHeap[K comparable, P any] struct {
+	entries []*pmEntry[K, P]
+	less    func(a, b P) bool
+}
+
+func NewPriorityMap[K comparable, P any](less func(a, b P) bool) *PriorityMap[K, P] {
+	return &PriorityMap[K, P]{
+		h:     pmHeap[K, P]{entries: make([]*pmEntry[K, P], 0), less: less},
+		byKey: make(map[K]*pmEntry[K, P]),
+	}
+}
+
+// Sort interface
+
+func (h pmHeap[K, P]) Len() int {
+	return len(h.entries)
+}
+
+func (h pmHeap[K, P]) Less(i, j int) bool {
+	return h.less(h.entries[i].priority, h.entries[j].priority)
+}
+
+func (h pmHeap[K, P]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+// Heap interface
+
+func (h *pmHeap[K, P]) Push(x any) {
+	e := x.(*pmEntry[K, P])
+	e.index = len(h.entries)
+	h.entries = append(h.entries, e)
+}
+
+func (h *pmHeap[K, P]) Pop() any {
+	n := len(h.entries)
+	old := h.entries[n-1]
+	h.entries[n-1] = nil
+	h.entries = h.entries[0 : n-1]
+	return old
+}
+
+// Set gives key the priority p, adding the key if it is new. Setting a key
+// again also repositions it when p is a pointer whose target was changed in
+// place.
+func (m *PriorityMap[K, P]) Set(key K, p P) {
+
+	if e, ok := m.byKey[key]; ok {
+		e.priority = p
+		heap.Fix(&m.h, e.index)
+		return
+	}
+
+	e := &pmEntry[K, P]{key: key, priority: p}
+	m.byKey[key] = e
+	heap.Push(&m.h, e)
+}
+
+// Delete removes key and returns the priority it held.
+func (m *PriorityMap[K, P]) Delete(key K) (P, bool) {
+
+	e, ok := m.byKey[key]
+	if !ok {
+		var zero P
+		return zero, false
+	}
+
+	heap.Remove(&m.h, e.index)
+	delete(m.byKey, key)
+
+	return e.priority, true
+}
+
+func (m *PriorityMap[K, P]) Get(key K) (P, bool) {
+
+	e, ok := m.byKey[key]
+	if !ok {
+		var zero P
+		return zero, false
+	}
+
+	return e.priority, true
+}
+
+func (m *PriorityMap[K, P]) Len() int {
+	return len(m.h.entries)
+}
+
+// PeekMin returns the key with the lowest priority without removing it.
+func (m *PriorityMap[K, P]) PeekMin() (K, P, bool) {
+
+	if len(m.h.entries) == 0 {
+		var key K
+		var p P
+		return key, p, false
+	}
+
+	e := m.h.entries[0]
+	return e.key, e.priority, true
+}
+
+// PopMin removes and returns the key with the lowest priority.
+func (m *PriorityMap[K, P]) PopMin() (K, P, bool) {
+
+	if len(m.h.entries) == 0 {
+		var key K
+		var p P
+		return key, p, false
+	}
+
+	e := heap.Pop(&m.h).(*pmEntry[K, P])
+	delete(m.byKey, e.key)
+
+	return e.key, e.priority, true
+}
+
+// pmCursor walks the heap in order without popping it. It is itself a heap
+// of positions in the map's heap: it starts at the root, and each time a
+// position is taken its two children become candidates, so reading the
+// first k entries costs O(k log k).
+type pmCursor[K comparable, P any] struct {
+	h   pmHeap[K, P]
+	pos []int
+}
+
+func (c pmCursor[K, P]) Len() int           { return len(c.pos) }
+func (c pmCursor[K, P]) Less(i, j int) bool { return c.h.Less(c.pos[i], c.pos[j]) }
+func (c pmCursor[K, P]) Swap(i, j int)      { c.pos[i], c.pos[j] = c.pos[j], c.pos[i] }
+
+func (c *pmCursor[K, P]) Push(x any) {
+	c.pos = append(c.pos, x.(int))
+}
+
+func (c *pmCursor[K, P]) Pop() any {
+	n := len(c.pos)
+	p := c.pos[n-1]
+	c.pos = c.pos[:n-1]
+	return p
+}
+
+// All yields every key and priority from lowest to highest without changing
+// the map. The map must not be modified while iterating.
+func (m *PriorityMap[K, P]) All() iter.Seq2[K, P] {
+	return func(yield func(K, P) bool) {
+
+		if len(m.h.entries) == 0 {
+			return
+		}
+
+		c := &pmCursor[K, P]{h: m.h, pos: []int{0}}
+
+		for len(c.pos) > 0 {
+			p := heap.Pop(c).(int)
+			e := m.h.entries[p]
+
+			if !yield(e.key, e.priority) {
+				return
+			}
+
+			for _, child := range []int{2*p + 1, 2*p + 2} {
+				if child < len(m.h.entries) {
+					heap.Push(c, child)
+				}
+			}
+		}
+	}
+}
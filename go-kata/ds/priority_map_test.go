@@ -0,0 +1,131 @@
+package ds
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func lessInt(a, b int) bool { return a < b }
+
+func TestPriorityMapSetGetDelete(t *testing.T) {
+	m := NewPriorityMap[string](lessInt)
+
+	m.Set("a", 3)
+	m.Set("b", 1)
+	m.Set("c", 2)
+
+	if m.Len() != 3 {
+		t.Fatalf("Expected length 3, got %d", m.Len())
+	}
+	if p, ok := m.Get("a"); !ok || p != 3 {
+		t.Errorf("Expected a=3, got %d, %v", p, ok)
+	}
+
+	if key, p, ok := m.PeekMin(); !ok || key != "b" || p != 1 {
+		t.Errorf("Expected b=1 at the root, got %s=%d", key, p)
+	}
+
+	// Reprioritising moves a key both ways
+	m.Set("a", 0)
+	if key, _, _ := m.PeekMin(); key != "a" {
+		t.Errorf("Expected a at the root after lowering it, got %s", key)
+	}
+	m.Set("a", 5)
+	if key, _, _ := m.PeekMin(); key != "b" {
+		t.Errorf("Expected b at the root after raising a, got %s", key)
+	}
+
+	if p, ok := m.Delete("b"); !ok || p != 1 {
+		t.Errorf("Expected to delete b=1, got %d, %v", p, ok)
+	}
+	if _, ok := m.Delete("b"); ok {
+		t.Error("Expected a second delete to fail")
+	}
+	if _, ok := m.Get("b"); ok {
+		t.Error("Expected b to be gone")
+	}
+	if m.Len() != 2 {
+		t.Errorf("Expected length 2, got %d", m.Len())
+	}
+}
+
+func TestPriorityMapPopMin(t *testing.T) {
+	m := NewPriorityMap[int](lessInt)
+
+	if _, _, ok := m.PopMin(); ok {
+		t.Error("Expected PopMin on an empty map to fail")
+	}
+	if _, _, ok := m.PeekMin(); ok {
+		t.Error("Expected PeekMin on an empty map to fail")
+	}
+
+	for _, k := range []int{5, 3, 8, 1, 9, 2} {
+		m.Set(k, k*10)
+	}
+
+	var keys []int
+	for m.Len() > 0 {
+		key, p, _ := m.PopMin()
+		if p != key*10 {
+			t.Errorf("Expected %d to hold %d, got %d", key, key*10, p)
+		}
+		keys = append(keys, key)
+	}
+
+	if !slices.Equal(keys, []int{1, 2, 3, 5, 8, 9}) {
+		t.Errorf("Expected keys in priority order, got %v", keys)
+	}
+	if _, ok := m.Get(1); ok {
+		t.Error("Expected popped keys to be gone")
+	}
+}
+
+func TestPriorityMapAll(t *testing.T) {
+	m := NewPriorityMap[int](lessInt)
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for k := range 500 {
+		m.Set(k, r.IntN(100))
+	}
+	for k := range 100 {
+		m.Delete(k * 3)
+	}
+
+	var seen []int
+	for key, p := range m.All() {
+		if q, _ := m.Get(key); q != p {
+			t.Fatalf("Key %d: expected %d, got %d", key, q, p)
+		}
+		seen = append(seen, p)
+	}
+
+	if len(seen) != m.Len() || !slices.IsSorted(seen) {
+		t.Errorf("Expected %d priorities in order, got %d", m.Len(), len(seen))
+	}
+
+	// Stopping early leaves the map as it was
+	for range m.All() {
+		break
+	}
+	if m.Len() != 400 {
+		t.Errorf("Expected 400 keys after iterating, got %d", m.Len())
+	}
+}
+
+func TestPriorityMapPointerPriority(t *testing.T) {
+	m := NewPriorityMap[string]((*Task).before)
+
+	a := &Task{ID: "a", deadline: epoch}
+	b := &Task{ID: "b", deadline: epoch}
+	m.Set("a", a)
+	m.Set("b", b)
+
+	// Changing a task in place and setting it again repositions it
+	b.priority = 1
+	m.Set("b", b)
+
+	if key, _, _ := m.PeekMin(); key != "b" {
+		t.Errorf("Expected b first after raising its priority, got %s", key)
+	}
+}
@@ -0,0 +1,129 @@
+package ds
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Limiter meters how many tasks may be released. Available reports how many
+// permits can be taken at now, Consume takes n of them, and NextAvailable
+// reports when at least one permit will be available.
+type Limiter interface {
+	Available(now time.Time) int
+	Consume(now time.Time, n int)
+	NextAvailable(now time.Time) time.Time
+}
+
+// TokenBucket refills at rate permits per second up to burst, and starts
+// full: after a quiet spell up to burst tasks go out at once, after which
+// they are released at the refill rate.
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int, now time.Time) (*TokenBucket, error) {
+
+	if rate <= 0 {
+		return nil, errors.New("Rate must be positive!!")
+	}
+
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(max(burst, 1)),
+		tokens: float64(max(burst, 1)),
+		last:   now,
+	}, nil
+}
+
+func (b *TokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+func (b *TokenBucket) Available(now time.Time) int {
+	b.refill(now)
+	return int(b.tokens)
+}
+
+func (b *TokenBucket) Consume(now time.Time, n int) {
+	b.refill(now)
+	b.tokens -= float64(n)
+}
+
+func (b *TokenBucket) NextAvailable(now time.Time) time.Time {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return now
+	}
+	return now.Add(time.Duration((1 - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// LeakyBucket releases one permit every interval and never bursts: a permit
+// that is not taken on time is not saved up for later.
+type LeakyBucket struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func NewLeakyBucket(interval time.Duration, now time.Time) (*LeakyBucket, error) {
+
+	if interval <= 0 {
+		return nil, errors.New("Interval must be positive!!")
+	}
+
+	return &LeakyBucket{interval: interval, next: now}, nil
+}
+
+func (b *LeakyBucket) Available(now time.Time) int {
+	if now.Before(b.next) {
+		return 0
+	}
+	return 1
+}
+
+func (b *LeakyBucket) Consume(now time.Time, n int) {
+	if n <= 0 {
+		return
+	}
+	b.next = b.next.Add(time.Duration(n) * b.interval)
+	if late := now.Add(time.Duration(n) * b.interval); b.next.Before(late) {
+		b.next = late
+	}
+}
+
+func (b *LeakyBucket) NextAvailable(now time.Time) time.Time {
+	if now.Before(b.next) {
+		return b.next
+	}
+	return now
+}
+
+// SetLimiter rate-limits PopDue: each call releases no more due tasks than
+// the limiter has permits for at the scheduler's current time. Due tasks
+// that are held back stay on the heap in deadline order. A nil limiter
+// removes the limit.
+func (s *Scheduler) SetLimiter(l Limiter) {
+	s.limiter = l
+}
+
+// NextRelease returns when PopDue will next hand out a task: the later of
+// the earliest deadline and the limiter's next permit.
+func (s *Scheduler) NextRelease() (time.Time, bool) {
+
+	next, ok := s.NextDeadline()
+	if !ok || s.limiter == nil {
+		return next, ok
+	}
+
+	if permit := s.limiter.NextAvailable(s.clock.Now()); permit.After(next) {
+		next = permit
+	}
+
+	return next, true
+}
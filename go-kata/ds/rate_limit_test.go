@@ -0,0 +1,161 @@
+package ds
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b, _ := NewTokenBucket(2, 3, epoch)
+
+	if got := b.Available(epoch); got != 3 {
+		t.Fatalf("Expected a full bucket of 3, got %d", got)
+	}
+
+	b.Consume(epoch, 3)
+	if got := b.Available(epoch); got != 0 {
+		t.Errorf("Expected an empty bucket, got %d", got)
+	}
+	if next := b.NextAvailable(epoch); !next.Equal(epoch.Add(500 * time.Millisecond)) {
+		t.Errorf("Expected the next token after 500ms, got %v", next.Sub(epoch))
+	}
+
+	if got := b.Available(epoch.Add(time.Second)); got != 2 {
+		t.Errorf("Expected 2 tokens after 1s, got %d", got)
+	}
+	if got := b.Available(epoch.Add(time.Hour)); got != 3 {
+		t.Errorf("Expected refill to stop at the burst size, got %d", got)
+	}
+}
+
+func TestLimitersRejectBadRates(t *testing.T) {
+	if _, err := NewTokenBucket(0, 1, epoch); err == nil {
+		t.Error("Expected error for a zero rate")
+	}
+	if _, err := NewTokenBucket(-1, 1, epoch); err == nil {
+		t.Error("Expected error for a negative rate")
+	}
+	if _, err := NewLeakyBucket(0, epoch); err == nil {
+		t.Error("Expected error for a zero interval")
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	b, _ := NewLeakyBucket(time.Second, epoch)
+
+	if b.Available(epoch) != 1 {
+		t.Fatal("Expected one permit at the start")
+	}
+	b.Consume(epoch, 1)
+
+	if b.Available(epoch.Add(500*time.Millisecond)) != 0 {
+		t.Error("Expected no permit before the interval")
+	}
+	if next := b.NextAvailable(epoch); !next.Equal(epoch.Add(time.Second)) {
+		t.Errorf("Expected the next permit at 1s, got %v", next.Sub(epoch))
+	}
+
+	// A long pause does not build up a burst
+	if b.Available(epoch.Add(time.Hour)) != 1 {
+		t.Error("Expected a single permit after a pause")
+	}
+	b.Consume(epoch.Add(time.Hour), 1)
+	if b.Available(epoch.Add(time.Hour)) != 0 {
+		t.Error("Expected no second permit after a pause")
+	}
+}
+
+func TestSchedulerWithTokenBucket(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	bucket, _ := NewTokenBucket(10, 5, epoch)
+	s.SetLimiter(bucket)
+
+	// A backlog builds up while nothing polls
+	for i := 0; i < 20; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%02d", i), epoch.Add(-time.Duration(20-i)*time.Second))
+	}
+
+	first := s.PopDueNow()
+	if got := ids(first); len(got) != 5 || got[0] != "t00" || got[4] != "t04" {
+		t.Fatalf("Expected the 5 earliest tasks as the burst, got %v", got)
+	}
+	if len(s.PopDueNow()) != 0 {
+		t.Error("Expected nothing more until tokens refill")
+	}
+
+	next, ok := s.NextRelease()
+	if !ok || !next.Equal(epoch.Add(100*time.Millisecond)) {
+		t.Errorf("Expected the next release at 100ms, got %v", next.Sub(epoch))
+	}
+
+	clock.Advance(300 * time.Millisecond)
+	if got := ids(s.PopDueN(epoch.Add(time.Hour), 0)); len(got) != 3 || got[0] != "t05" {
+		t.Errorf("Expected 3 tasks after 300ms, got %v", got)
+	}
+
+	// An explicit limit below the permits still applies
+	clock.Advance(time.Second)
+	if got := s.PopDueN(clock.Now(), 2); len(got) != 2 {
+		t.Errorf("Expected the explicit limit of 2, got %d", len(got))
+	}
+	if got := s.PopDueNow(); len(got) != 3 {
+		t.Errorf("Expected the 3 tokens left over, got %d", len(got))
+	}
+
+	s.SetLimiter(nil)
+	if got := s.PopDueNow(); len(got) != 7 {
+		t.Errorf("Expected the rest of the backlog without a limiter, got %d", len(got))
+	}
+}
+
+func TestSchedulerWithLeakyBucket(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	bucket, _ := NewLeakyBucket(time.Second, epoch)
+	s.SetLimiter(bucket)
+
+	for i := 0; i < 3; i++ {
+		s.AddOrUpdate(fmt.Sprintf("t%d", i), epoch.Add(-time.Duration(3-i)*time.Millisecond))
+	}
+
+	var released []string
+	for step := 0; step < 6; step++ {
+		for _, task := range s.PopDueNow() {
+			released = append(released, fmt.Sprintf("%s@%v", task.ID, clock.Now().Sub(epoch)))
+		}
+		clock.Advance(500 * time.Millisecond)
+	}
+
+	expected := []string{"t0@0s", "t1@1s", "t2@2s"}
+	if len(released) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, released)
+	}
+	for i := range expected {
+		if released[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, released)
+		}
+	}
+}
+
+func TestLimiterIgnoresFutureTasks(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	bucket, _ := NewTokenBucket(1, 2, epoch)
+	s.SetLimiter(bucket)
+
+	s.AddOrUpdate("later", epoch.Add(time.Minute))
+
+	if len(s.PopDueNow()) != 0 {
+		t.Fatal("Expected nothing due")
+	}
+	if got := bucket.Available(epoch); got != 2 {
+		t.Errorf("Expected an empty pop not to use tokens, got %d left", got)
+	}
+
+	next, _ := s.NextRelease()
+	if !next.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Expected the next release at the deadline, got %v", next.Sub(epoch))
+	}
+}
@@ -0,0 +1,140 @@
+package ds
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Recurrence yields the occurrences of a periodic task. Next returns the
+// first occurrence strictly after prev, or the zero time when there are no
+// more.
+type Recurrence interface {
+	Next(prev time.Time) time.Time
+}
+
+type every time.Duration
+
+// Every recurs at a fixed interval from the first occurrence.
+func Every(d time.Duration) (Recurrence, error) {
+	if d <= 0 {
+		return nil, errors.New("Interval must be positive!!")
+	}
+	return every(d), nil
+}
+
+func (e every) Next(prev time.Time) time.Time {
+	return prev.Add(time.Duration(e))
+}
+
+// CatchUp decides what happens to occurrences that were missed because
+// PopDue was not called until after the following occurrence was also due.
+type CatchUp int
+
+const (
+	// CatchUpOnce runs a late task once and resumes at the next future
+	// occurrence.
+	CatchUpOnce CatchUp = iota
+	// CatchUpSkip drops a late occurrence, and any others missed with it,
+	// and resumes at the next future occurrence.
+	CatchUpSkip
+	// CatchUpAll runs every missed occurrence, each as its own task.
+	CatchUpAll
+)
+
+// Recurring configures a periodic task. Jitter delays each occurrence by a
+// random amount in [0, Jitter) without shifting the underlying schedule.
+type Recurring struct {
+	Schedule Recurrence
+	Jitter   time.Duration
+	CatchUp  CatchUp
+}
+
+type recurrence struct {
+	Recurring
+	nominal time.Time // current occurrence before jitter
+}
+
+// AddOrUpdateRecurring schedules ID at the first occurrence of r after the
+// scheduler's current time. Every time PopDue hands the task out, its next
+// occurrence is queued under the same ID. Updating the ID later with
+// AddOrUpdate turns it back into a one-shot task.
+func (s *Scheduler) AddOrUpdateRecurring(ID string, r Recurring, opts ...TaskOption) error {
+
+	if r.Schedule == nil {
+		return errors.New("Recurring task needs a schedule!!")
+	}
+
+	now := s.clock.Now()
+	first := r.Schedule.Next(now)
+	if first.IsZero() {
+		return errors.New("Schedule has no future occurrence!!")
+	}
+	if !first.After(now) {
+		return errors.New("Schedule must move forward!!")
+	}
+
+	rec := &recurrence{Recurring: r, nominal: first}
+	s.AddOrUpdate(ID, rec.jittered(), opts...)
+	s.byID[ID].recur = rec
+
+	return nil
+}
+
+func (r *recurrence) jittered() time.Time {
+	if r.Jitter <= 0 {
+		return r.nominal
+	}
+	return r.nominal.Add(time.Duration(rand.Int64N(int64(r.Jitter))))
+}
+
+// step moves r to its next occurrence. A schedule that does not move
+// forward ends the recurrence rather than looping forever.
+func (r *recurrence) step() {
+	next := r.Schedule.Next(r.nominal)
+	if !next.After(r.nominal) {
+		next = time.Time{}
+	}
+	r.nominal = next
+}
+
+// advance moves r past every occurrence at or before now.
+func (r *recurrence) advance(now time.Time) {
+	for !r.nominal.IsZero() && !r.nominal.After(now) {
+		r.step()
+	}
+}
+
+// popRecurring handles a due recurring task that has just been popped from
+// the heap: it re-queues the next occurrence and returns the occurrence to
+// hand out, if any.
+func (s *Scheduler) popRecurring(t *Task, now time.Time) (*Task, bool) {
+
+	r := t.recur
+
+	occurrence := *t
+	occurrence.recur = nil
+
+	next := r.Schedule.Next(r.nominal)
+	late := !next.IsZero() && !next.After(now)
+
+	if r.CatchUp == CatchUpAll {
+		// The next occurrence may already be due; it goes back on the
+		// heap and comes out again in this same PopDue
+		r.step()
+	} else {
+		r.advance(now)
+	}
+
+	if !r.nominal.IsZero() {
+		t.deadline = r.jittered()
+		s.byID[t.ID] = t
+		s.ready.Set(t.ID, t)
+	}
+
+	if late && r.CatchUp == CatchUpSkip {
+		return nil, false
+	}
+
+	return &occurrence, true
+}
@@ -0,0 +1,202 @@
+package ds
+
+import (
+	"testing"
+	"time"
+)
+
+func mustEvery(d time.Duration) Recurrence {
+	r, err := Every(d)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// stalled is a schedule whose every occurrence is at.
+type stalled struct{ at time.Time }
+
+func (s stalled) Next(time.Time) time.Time { return s.at }
+
+func ids(tasks []*Task) []string {
+	out := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, t.ID)
+	}
+	return out
+}
+
+func TestRecurringInterval(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	if err := s.AddOrUpdateRecurring("tick", Recurring{Schedule: mustEvery(time.Minute)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		clock.Advance(time.Minute)
+
+		due := s.PopDueNow()
+		if len(due) != 1 || due[0].ID != "tick" {
+			t.Fatalf("Run %d: expected tick to be due, got %v", i, ids(due))
+		}
+		if !due[0].deadline.Equal(epoch.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("Run %d: expected deadline %v, got %v", i, epoch.Add(time.Duration(i)*time.Minute), due[0].deadline)
+		}
+	}
+
+	// The next occurrence is queued under the same ID
+	if s.Len() != 1 || len(s.byID) != 1 {
+		t.Errorf("Expected the next occurrence to be queued, got heap %d byID %d", s.Len(), len(s.byID))
+	}
+
+	if !s.Remove("tick") {
+		t.Error("Expected Remove to cancel the recurring task")
+	}
+	clock.Advance(time.Hour)
+	if due := s.PopDueNow(); len(due) != 0 {
+		t.Errorf("Expected no runs after Remove, got %v", ids(due))
+	}
+}
+
+func TestRecurringCatchUp(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        CatchUp
+		expectedRuns  int
+		expectedFirst time.Duration // deadline of the first run, after epoch
+	}{
+		{name: "once", policy: CatchUpOnce, expectedRuns: 1, expectedFirst: time.Minute},
+		{name: "skip", policy: CatchUpSkip, expectedRuns: 0},
+		{name: "all", policy: CatchUpAll, expectedRuns: 5, expectedFirst: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(epoch)
+			s := CreateSchedulerWithClock(clock)
+			s.AddOrUpdateRecurring("job", Recurring{Schedule: mustEvery(time.Minute), CatchUp: tt.policy})
+
+			// Occurrences at 1m..5m have all passed
+			clock.Advance(5*time.Minute + 30*time.Second)
+
+			due := s.PopDueNow()
+			if len(due) != tt.expectedRuns {
+				t.Fatalf("Expected %d runs, got %d", tt.expectedRuns, len(due))
+			}
+			if len(due) > 0 && !due[0].deadline.Equal(epoch.Add(tt.expectedFirst)) {
+				t.Errorf("Expected first run at %v, got %v", epoch.Add(tt.expectedFirst), due[0].deadline)
+			}
+
+			// Every policy resumes at the next future occurrence
+			if !root(s).deadline.Equal(epoch.Add(6 * time.Minute)) {
+				t.Errorf("Expected next occurrence at 6m, got %v", root(s).deadline.Sub(epoch))
+			}
+		})
+	}
+}
+
+func TestRecurringOnTimeIsNotSkipped(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.AddOrUpdateRecurring("job", Recurring{Schedule: mustEvery(time.Minute), CatchUp: CatchUpSkip})
+
+	// Late, but not by a whole period
+	clock.Advance(time.Minute + 30*time.Second)
+
+	if due := s.PopDueNow(); len(due) != 1 {
+		t.Errorf("Expected 1 run, got %d", len(due))
+	}
+}
+
+func TestRecurringCron(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 10, 10, 20, 0, 0, time.UTC))
+	s := CreateSchedulerWithClock(clock)
+
+	c, _ := ParseCron("*/15 * * * *")
+	s.AddOrUpdateRecurring("report", Recurring{Schedule: c})
+
+	if !root(s).deadline.Equal(time.Date(2024, 3, 10, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected first run at 10:30, got %v", root(s).deadline)
+	}
+
+	clock.Advance(10 * time.Minute)
+	s.PopDueNow()
+
+	if !root(s).deadline.Equal(time.Date(2024, 3, 10, 10, 45, 0, 0, time.UTC)) {
+		t.Errorf("Expected next run at 10:45, got %v", root(s).deadline)
+	}
+}
+
+func TestRecurringJitter(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.AddOrUpdateRecurring("job", Recurring{Schedule: mustEvery(time.Hour), Jitter: time.Minute})
+
+	for i := 1; i <= 20; i++ {
+		nominal := epoch.Add(time.Duration(i) * time.Hour)
+		deadline := root(s).deadline
+
+		if deadline.Before(nominal) || !deadline.Before(nominal.Add(time.Minute)) {
+			t.Fatalf("Run %d: deadline %v outside [%v, %v)", i, deadline, nominal, nominal.Add(time.Minute))
+		}
+
+		// Jitter must not make the schedule drift
+		clock.Set(deadline)
+		if due := s.PopDueNow(); len(due) != 1 {
+			t.Fatalf("Run %d: expected 1 run, got %d", i, len(due))
+		}
+	}
+}
+
+func TestRecurringUpdateMakesOneShot(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.AddOrUpdateRecurring("job", Recurring{Schedule: mustEvery(time.Minute)})
+
+	s.AddOrUpdate("job", epoch.Add(time.Minute))
+	clock.Advance(time.Minute)
+
+	if due := s.PopDueNow(); len(due) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(due))
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected no further occurrences, got heap length %d", s.Len())
+	}
+}
+
+func TestRecurringErrors(t *testing.T) {
+	s := CreateScheduler()
+
+	if err := s.AddOrUpdateRecurring("job", Recurring{}); err == nil {
+		t.Error("Expected error for missing schedule")
+	}
+
+	c, _ := ParseCron("0 0 30 2 *")
+	if err := s.AddOrUpdateRecurring("job", Recurring{Schedule: c}); err == nil {
+		t.Error("Expected error for a schedule with no occurrences")
+	}
+
+	if _, err := Every(0); err == nil {
+		t.Error("Expected error for a zero interval")
+	}
+	if _, err := Every(-time.Minute); err == nil {
+		t.Error("Expected error for a negative interval")
+	}
+	if err := s.AddOrUpdateRecurring("job", Recurring{Schedule: stalled{epoch}}); err == nil {
+		t.Error("Expected error for a schedule that does not move past now")
+	}
+
+	// A schedule that stops moving forward ends after its last run
+	clock := NewFakeClock(epoch)
+	s = CreateSchedulerWithClock(clock)
+	s.AddOrUpdateRecurring("stuck", Recurring{Schedule: stalled{epoch.Add(time.Minute)}, CatchUp: CatchUpAll})
+	clock.Advance(time.Minute)
+	if due := s.PopDueNow(); len(due) != 1 {
+		t.Errorf("Expected 1 run, got %d", len(due))
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected the stuck schedule to end, got heap length %d", s.Len())
+	}
+}
@@ -0,0 +1,153 @@
+package ds
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+var ErrNotInFlight = errors.New("Task is not in flight!!")
+
+// RetryPolicy decides when a failed task runs again. The n-th retry waits
+// BaseDelay * Multiplier^(n-1), capped at MaxDelay, and then shortened by a
+// random fraction of up to Jitter so that tasks failing together do not
+// retry together. After MaxAttempts failures the task is dead-lettered;
+// MaxAttempts <= 0 retries forever.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Multiplier  float64
+	Jitter      float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   time.Second,
+	MaxDelay:    5 * time.Minute,
+	Multiplier:  2,
+	Jitter:      0.2,
+}
+
+// Backoff returns the delay before the retry that follows the given number
+// of failed attempts.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+
+	delay := float64(p.BaseDelay) * math.Pow(max(p.Multiplier, 1), float64(max(attempts-1, 0)))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay -= delay * min(p.Jitter, 1) * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// SetRetryPolicy turns on failure handling. From then on every task PopDue
+// hands out is held in flight until it is Acked or Nacked. Occurrences of
+// recurring tasks are not tracked, since their next run is already queued.
+func (s *Scheduler) SetRetryPolicy(p RetryPolicy) {
+	s.retry = &p
+}
+
+// Ack reports that an in-flight or leased task finished.
+func (s *Scheduler) Ack(ID string) bool {
+	_, ok := s.takeInFlight(ID)
+	return ok
+}
+
+// takeInFlight removes ID from the tasks handed out and not yet finished.
+func (s *Scheduler) takeInFlight(ID string) (*Task, bool) {
+	if t, ok := s.inflight[ID]; ok {
+		delete(s.inflight, ID)
+		return t, true
+	}
+	if s.leases != nil {
+		if l, ok := s.leases.byID[ID]; ok {
+			s.leases.Remove(ID)
+			return l.Payload.(*lease).task, true
+		}
+	}
+	return nil, false
+}
+
+// Nack reports that an in-flight or leased task failed with cause. The task
+// is rescheduled after the policy's backoff and Nack returns true, or, once
+// it has used up its attempts, it is moved to the dead letters and Nack
+// returns false. If the ID was scheduled again while the task was in
+// flight, the new task is kept and the failed one is dropped.
+func (s *Scheduler) Nack(ID string, cause error) (bool, error) {
+
+	t, ok := s.takeInFlight(ID)
+	if !ok {
+		return false, ErrNotInFlight
+	}
+
+	return s.fail(t, cause), nil
+}
+
+// fail records a failed attempt of t, which has been handed out, and
+// requeues it unless it is dead-lettered. Without a retry policy it is
+// requeued to run straight away.
+func (s *Scheduler) fail(t *Task, cause error) bool {
+
+	now := s.clock.Now()
+
+	t.attempts++
+	t.lastErr = cause
+	t.updated = now
+
+	if s.retry != nil && s.retry.MaxAttempts > 0 && t.attempts >= s.retry.MaxAttempts {
+		s.dead[t.ID] = t
+		return false
+	}
+
+	if _, queued := s.byID[t.ID]; queued {
+		return true
+	}
+
+	t.deadline = now
+	if s.retry != nil {
+		t.deadline = now.Add(s.retry.Backoff(t.attempts))
+	}
+	s.stamp(t)
+	s.byID[t.ID] = t
+
+	if !s.blocked(t.ID) {
+		s.ready.Set(t.ID, t)
+	}
+	s.reportDepth()
+
+	return true
+}
+
+// DeadLetter returns the task that exhausted its retries under ID.
+func (s *Scheduler) DeadLetter(ID string) (*Task, bool) {
+	t, ok := s.dead[ID]
+	return t, ok
+}
+
+// DeadLetters returns every dead-lettered task, ordered by ID.
+func (s *Scheduler) DeadLetters() []*Task {
+
+	dead := make([]*Task, 0, len(s.dead))
+	for _, t := range s.dead {
+		dead = append(dead, t)
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].ID < dead[j].ID })
+
+	return dead
+}
+
+// RemoveDeadLetter forgets a dead-lettered task.
+func (s *Scheduler) RemoveDeadLetter(ID string) bool {
+	if _, ok := s.dead[ID]; !ok {
+		return false
+	}
+	delete(s.dead, ID)
+	return true
+}
@@ -0,0 +1,154 @@
+package ds
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := p.Backoff(i + 1); got != want {
+			t.Errorf("Backoff(%d): expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, Multiplier: 2, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		got := p.Backoff(3)
+		if got <= 2*time.Second || got > 4*time.Second {
+			t.Fatalf("Expected a delay in (2s, 4s], got %v", got)
+		}
+	}
+}
+
+func TestNackReschedulesWithBackoff(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, Multiplier: 2})
+
+	s.AddOrUpdate("job", epoch, WithPayload("data"), WithPriority(4))
+
+	boom := errors.New("boom")
+
+	due := s.PopDueNow()
+	if len(due) != 1 {
+		t.Fatalf("Expected job to be due, got %v", ids(due))
+	}
+
+	if retrying, err := s.Nack("job", boom); !retrying || err != nil {
+		t.Fatalf("Expected a retry, got %v, %v", retrying, err)
+	}
+
+	if len(s.PopDueNow()) != 0 {
+		t.Error("Expected the retry to wait for its backoff")
+	}
+
+	clock.Advance(time.Second)
+	due = s.PopDueNow()
+	if len(due) != 1 {
+		t.Fatalf("Expected the retry after 1s, got %v", ids(due))
+	}
+
+	retried := due[0]
+	if retried.Attempts() != 1 || !errors.Is(retried.LastError(), boom) {
+		t.Errorf("Expected 1 attempt failing with boom, got %d, %v", retried.Attempts(), retried.LastError())
+	}
+	if retried.Payload != "data" || retried.Priority() != 4 {
+		t.Errorf("Expected payload and priority to survive, got %v, %d", retried.Payload, retried.Priority())
+	}
+
+	s.Nack("job", boom)
+
+	clock.Advance(time.Second)
+	if len(s.PopDueNow()) != 0 {
+		t.Error("Expected the second retry to wait 2s")
+	}
+	clock.Advance(time.Second)
+	if len(s.PopDueNow()) != 1 {
+		t.Fatal("Expected the second retry after 2s")
+	}
+
+	if retrying, _ := s.Nack("job", boom); retrying {
+		t.Error("Expected the third failure to dead-letter the task")
+	}
+
+	dead, ok := s.DeadLetter("job")
+	if !ok || dead.Attempts() != 3 {
+		t.Fatalf("Expected job in the dead letters after 3 attempts, got %v", dead)
+	}
+
+	clock.Advance(time.Hour)
+	if len(s.PopDueNow()) != 0 {
+		t.Error("Expected a dead-lettered task not to be scheduled")
+	}
+
+	if got := s.DeadLetters(); len(got) != 1 || got[0].ID != "job" {
+		t.Errorf("Expected [job] in the dead letters, got %v", ids(got))
+	}
+	if !s.RemoveDeadLetter("job") || len(s.DeadLetters()) != 0 {
+		t.Error("Expected the dead letter to be removed")
+	}
+}
+
+func TestAckAndNackRequireInFlight(t *testing.T) {
+	s := CreateScheduler()
+	s.SetRetryPolicy(DefaultRetryPolicy)
+
+	now := time.Now()
+	s.AddOrUpdate("job", now)
+
+	if s.Ack("job") {
+		t.Error("Expected Ack of a queued task to fail")
+	}
+	if _, err := s.Nack("job", nil); !errors.Is(err, ErrNotInFlight) {
+		t.Errorf("Expected ErrNotInFlight, got %v", err)
+	}
+
+	s.PopDue(now)
+
+	if !s.Ack("job") {
+		t.Error("Expected Ack of an in-flight task to succeed")
+	}
+	if s.Ack("job") {
+		t.Error("Expected a second Ack to fail")
+	}
+}
+
+func TestNackKeepsNewerSchedule(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+	s.SetRetryPolicy(RetryPolicy{BaseDelay: time.Minute})
+
+	s.AddOrUpdate("job", epoch)
+	s.PopDueNow()
+
+	s.AddOrUpdate("job", epoch.Add(time.Hour), WithPayload("new"))
+
+	if retrying, err := s.Nack("job", errors.New("boom")); !retrying || err != nil {
+		t.Fatalf("Expected Nack to succeed, got %v, %v", retrying, err)
+	}
+
+	task := s.byID["job"]
+	if task.Payload != "new" || !task.Deadline().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Expected the newer schedule to win, got %v at %v", task.Payload, task.Deadline())
+	}
+}
+
+func TestNoInFlightTrackingWithoutPolicy(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("job", now)
+	s.PopDue(now)
+
+	if len(s.inflight) != 0 {
+		t.Errorf("Expected nothing in flight without a retry policy, got %d", len(s.inflight))
+	}
+}
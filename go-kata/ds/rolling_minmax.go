@@ -1,12 +1,10 @@
 package ds
 
-import (
-	"fmt"
-)
+import "go-kata/internal/tracing"
 
 func RollingMinMax(nums []int, k int) (mins []int, maxs []int, err error) {
 
-	fmt.Println("-------------------------------------------------------------------------------------")
+	trace := tracing.Logger()
 
 	maxQ := NewDeque(k) // Monotonic ordering (indexes)
 	minQ := NewDeque(k)
@@ -16,18 +14,20 @@ func RollingMinMax(nums []int, k int) (mins []int, maxs []int, err error) {
 
 	k = min(len(nums), k)
 
-	purge := func(Q *Deque, curr_idx int) {
+	purge := func(Q *Deque, name string, curr_idx int) {
 		for !Q.Empty() {
 			best_idx, _ := Q.PeekFront()
 			if best_idx > curr_idx-k {
 				break
 			}
 			front_idx, _ := Q.PopFront()
-			fmt.Println("Purging idx:", front_idx, "value:", nums[front_idx])
+			if trace != nil {
+				trace.Debug("purge", "queue", name, "idx", front_idx, "value", nums[front_idx])
+			}
 		}
 	}
 
-	monotonic_push := func(Q *Deque, new_idx int, pop_cond func(old int, new int) bool) {
+	monotonic_push := func(Q *Deque, name string, new_idx int, pop_cond func(old int, new int) bool) {
 		new_value := nums[new_idx]
 
 		for !Q.Empty() {
@@ -37,30 +37,29 @@ func RollingMinMax(nums []int, k int) (mins []int, maxs []int, err error) {
 				break
 			}
 			Q.PopBack()
-			fmt.Println("PopBack: ", nums[back_idx])
+			if trace != nil {
+				trace.Debug("pop back", "queue", name, "idx", back_idx, "value", old_value)
+			}
 		}
 
 		Q.PushBack(new_idx)
-		fmt.Println("Pushback:", new_value)
+		if trace != nil {
+			trace.Debug("push back", "queue", name, "idx", new_idx, "value", new_value)
+		}
 	}
 
 	for curr_idx, num := range nums {
-		fmt.Println("Processing idx:", curr_idx, "=>", num)
+		if trace != nil {
+			trace.Debug("process", "idx", curr_idx, "value", num)
+		}
 
 		// Drop any expired elements
-		fmt.Println("Purging maxQ")
-		purge(maxQ, curr_idx)
-
-		fmt.Println("Purging minQ")
-		purge(minQ, curr_idx)
+		purge(maxQ, "maxQ", curr_idx)
+		purge(minQ, "minQ", curr_idx)
 
 		// Ensure monotonicity
-
-		fmt.Println("Pushing to maxQ")
-		monotonic_push(maxQ, curr_idx, func(old_value, new_value int) bool { return old_value > new_value })
-
-		fmt.Println("Pushing to minQ")
-		monotonic_push(minQ, curr_idx, func(old_value, new_value int) bool { return old_value < new_value })
+		monotonic_push(maxQ, "maxQ", curr_idx, func(old_value, new_value int) bool { return old_value > new_value })
+		monotonic_push(minQ, "minQ", curr_idx, func(old_value, new_value int) bool { return old_value < new_value })
 
 		if curr_idx >= k-1 {
 			max_idx, _ := maxQ.PeekFront()
@@ -72,7 +71,9 @@ func RollingMinMax(nums []int, k int) (mins []int, maxs []int, err error) {
 			maxs = append(maxs, max_value)
 			mins = append(mins, min_value)
 
-			fmt.Println("Rolling max:", max_value, "min:", min_value)
+			if trace != nil {
+				trace.Debug("window", "idx", curr_idx, "min", min_value, "max", max_value)
+			}
 		}
 	}
 
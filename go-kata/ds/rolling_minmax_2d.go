@@ -0,0 +1,117 @@
+package ds
+
+import (
+	"errors"
+)
+
+// Grid is a Rows×Cols matrix in a flat row-major buffer with element (r, c)
+// at Data[r*Stride+c]. Stride may exceed Cols when the grid is a view into a
+// wider buffer.
+type Grid struct {
+	Data   []int
+	Rows   int
+	Cols   int
+	Stride int
+}
+
+func NewGrid(rows, cols int) Grid {
+	return Grid{Data: make([]int, rows*cols), Rows: rows, Cols: cols, Stride: cols}
+}
+
+func (g Grid) At(r, c int) int { return g.Data[r*g.Stride+c] }
+
+func (g Grid) row(r int) []int { return g.Data[r*g.Stride : r*g.Stride+g.Cols] }
+
+func (g Grid) rows() [][]int {
+	out := make([][]int, g.Rows)
+	for r := range out {
+		out[r] = append([]int(nil), g.row(r)...)
+	}
+	return out
+}
+
+func (g Grid) valid() bool {
+	if g.Rows < 0 || g.Cols < 0 || g.Stride < g.Cols {
+		return false
+	}
+	return g.Rows == 0 || len(g.Data) >= (g.Rows-1)*g.Stride+g.Cols
+}
+
+// RollingMinMaxGrid computes the min and max of every k×k sub-matrix by
+// running RollingMinMax along each row and then along each column of the row
+// results, for O(Rows*Cols) total work. As in RollingMinMax, k is clamped to
+// the grid size in each dimension.
+func RollingMinMaxGrid(g Grid, k int) (mins Grid, maxs Grid, err error) {
+
+	if k <= 0 {
+		return Grid{}, Grid{}, errors.New("Window size must be positive!!")
+	}
+	if !g.valid() {
+		return Grid{}, Grid{}, errors.New("Grid dimensions do not match its buffer!!")
+	}
+	if g.Rows == 0 || g.Cols == 0 {
+		return NewGrid(0, 0), NewGrid(0, 0), nil
+	}
+
+	out_cols := g.Cols - min(k, g.Cols) + 1
+	out_rows := g.Rows - min(k, g.Rows) + 1
+
+	// Row pass: horizontal k-windows of every row
+	row_mins := NewGrid(g.Rows, out_cols)
+	row_maxs := NewGrid(g.Rows, out_cols)
+
+	for r := 0; r < g.Rows; r++ {
+		r_mins, r_maxs, _ := RollingMinMax(g.row(r), k)
+		copy(row_mins.row(r), r_mins)
+		copy(row_maxs.row(r), r_maxs)
+	}
+
+	// Column pass: vertical k-windows over the row results
+	mins = NewGrid(out_rows, out_cols)
+	maxs = NewGrid(out_rows, out_cols)
+
+	column := make([]int, g.Rows)
+
+	for c := 0; c < out_cols; c++ {
+		for r := 0; r < g.Rows; r++ {
+			column[r] = row_mins.At(r, c)
+		}
+		c_mins, _, _ := RollingMinMax(column, k)
+
+		for r := 0; r < g.Rows; r++ {
+			column[r] = row_maxs.At(r, c)
+		}
+		_, c_maxs, _ := RollingMinMax(column, k)
+
+		for r := 0; r < out_rows; r++ {
+			mins.Data[r*mins.Stride+c] = c_mins[r]
+			maxs.Data[r*maxs.Stride+c] = c_maxs[r]
+		}
+	}
+
+	return mins, maxs, nil
+}
+
+// RollingMinMax2D is RollingMinMaxGrid over a [][]int. All rows must have the
+// same length.
+func RollingMinMax2D(grid [][]int, k int) (mins [][]int, maxs [][]int, err error) {
+
+	g := NewGrid(len(grid), 0)
+	if len(grid) > 0 {
+		g = NewGrid(len(grid), len(grid[0]))
+	}
+
+	for r, row := range grid {
+		if len(row) != g.Cols {
+			return nil, nil, errors.New("Grid rows must all have the same length!!")
+		}
+		copy(g.row(r), row)
+	}
+
+	min_grid, max_grid, err := RollingMinMaxGrid(g, k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return min_grid.rows(), max_grid.rows(), nil
+}
@@ -0,0 +1,157 @@
+package ds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func bruteMinMax2D(grid [][]int, k int) (mins [][]int, maxs [][]int) {
+	kr := min(k, len(grid))
+	kc := min(k, len(grid[0]))
+
+	for r := 0; r+kr <= len(grid); r++ {
+		var min_row, max_row []int
+		for c := 0; c+kc <= len(grid[0]); c++ {
+			lo, hi := grid[r][c], grid[r][c]
+			for i := r; i < r+kr; i++ {
+				for j := c; j < c+kc; j++ {
+					lo = min(lo, grid[i][j])
+					hi = max(hi, grid[i][j])
+				}
+			}
+			min_row = append(min_row, lo)
+			max_row = append(max_row, hi)
+		}
+		mins = append(mins, min_row)
+		maxs = append(maxs, max_row)
+	}
+
+	return mins, maxs
+}
+
+func TestRollingMinMax2D_BasicFunctionality(t *testing.T) {
+	tests := []struct {
+		name         string
+		grid         [][]int
+		k            int
+		expectedMins [][]int
+		expectedMaxs [][]int
+	}{
+		{
+			name: "3x3 with k=2",
+			grid: [][]int{
+				{1, 2, 3},
+				{4, 5, 6},
+				{7, 8, 9},
+			},
+			k:            2,
+			expectedMins: [][]int{{1, 2}, {4, 5}},
+			expectedMaxs: [][]int{{5, 6}, {8, 9}},
+		},
+		{
+			name: "window size 1",
+			grid: [][]int{
+				{3, -1},
+				{0, 7},
+			},
+			k:            1,
+			expectedMins: [][]int{{3, -1}, {0, 7}},
+			expectedMaxs: [][]int{{3, -1}, {0, 7}},
+		},
+		{
+			name: "k clamped to a short grid",
+			grid: [][]int{
+				{4, 1, 6, 2},
+			},
+			k:            2,
+			expectedMins: [][]int{{1, 1, 2}},
+			expectedMaxs: [][]int{{4, 6, 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mins, maxs, err := RollingMinMax2D(tt.grid, tt.k)
+			if err != nil {
+				t.Errorf("RollingMinMax2D(%v, %d) returned error: %v", tt.grid, tt.k, err)
+				return
+			}
+			if !reflect.DeepEqual(mins, tt.expectedMins) {
+				t.Errorf("RollingMinMax2D(%v, %d) mins = %v, want %v", tt.grid, tt.k, mins, tt.expectedMins)
+			}
+			if !reflect.DeepEqual(maxs, tt.expectedMaxs) {
+				t.Errorf("RollingMinMax2D(%v, %d) maxs = %v, want %v", tt.grid, tt.k, maxs, tt.expectedMaxs)
+			}
+		})
+	}
+}
+
+func TestRollingMinMax2D_MatchesBruteForce(t *testing.T) {
+	grid := make([][]int, 7)
+	for r := range grid {
+		grid[r] = make([]int, 9)
+		for c := range grid[r] {
+			grid[r][c] = (r*31+c*17)%23 - 11
+		}
+	}
+
+	for k := 1; k <= 9; k++ {
+		mins, maxs, err := RollingMinMax2D(grid, k)
+		if err != nil {
+			t.Fatalf("k=%d returned error: %v", k, err)
+		}
+
+		expectedMins, expectedMaxs := bruteMinMax2D(grid, k)
+		if !reflect.DeepEqual(mins, expectedMins) {
+			t.Errorf("k=%d mins = %v, want %v", k, mins, expectedMins)
+		}
+		if !reflect.DeepEqual(maxs, expectedMaxs) {
+			t.Errorf("k=%d maxs = %v, want %v", k, maxs, expectedMaxs)
+		}
+	}
+}
+
+func TestRollingMinMaxGrid_Strided(t *testing.T) {
+	// A 2x3 view into a buffer with 5 columns; the padding must be ignored
+	g := Grid{
+		Data: []int{
+			1, 9, 2, 100, 100,
+			8, 3, 7, -100, -100,
+		},
+		Rows:   2,
+		Cols:   3,
+		Stride: 5,
+	}
+
+	mins, maxs, err := RollingMinMaxGrid(g, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mins.Rows != 1 || mins.Cols != 2 {
+		t.Fatalf("Expected 1x2 result, got %dx%d", mins.Rows, mins.Cols)
+	}
+	if mins.At(0, 0) != 1 || mins.At(0, 1) != 2 {
+		t.Errorf("Expected mins [1 2], got %v", mins.Data)
+	}
+	if maxs.At(0, 0) != 9 || maxs.At(0, 1) != 9 {
+		t.Errorf("Expected maxs [9 9], got %v", maxs.Data)
+	}
+}
+
+func TestRollingMinMax2D_Errors(t *testing.T) {
+	if _, _, err := RollingMinMax2D([][]int{{1, 2}, {3}}, 1); err == nil {
+		t.Error("Expected error for ragged grid")
+	}
+	if _, _, err := RollingMinMax2D([][]int{{1}}, 0); err == nil {
+		t.Error("Expected error for window size 0")
+	}
+	if _, _, err := RollingMinMaxGrid(Grid{Data: []int{1, 2}, Rows: 2, Cols: 2, Stride: 2}, 1); err == nil {
+		t.Error("Expected error for a buffer smaller than the grid")
+	}
+
+	mins, maxs, err := RollingMinMax2D([][]int{}, 3)
+	if err != nil || len(mins) != 0 || len(maxs) != 0 {
+		t.Errorf("Expected empty result for empty grid, got %v %v %v", mins, maxs, err)
+	}
+}
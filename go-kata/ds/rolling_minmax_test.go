@@ -1,7 +1,10 @@
 package ds
 
 import (
+	"bytes"
+	"log/slog"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -334,3 +337,37 @@ func TestRollingMinMax_LargeK(t *testing.T) {
 		})
 	}
 }
+
+func TestRollingMinMax_Tracer(t *testing.T) {
+	var buf bytes.Buffer
+	SetTracer(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetTracer(nil) })
+
+	RollingMinMax([]int{3, 1, 2}, 2)
+
+	out := buf.String()
+	for _, want := range []string{"msg=process", "msg=purge", "msg=\"pop back\"", "msg=\"push back\"", "msg=window idx=2 min=1 max=2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected trace to contain %q, got %q", want, out)
+		}
+	}
+
+	// Handlers above debug level see nothing
+	buf.Reset()
+	SetTracer(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	RollingMinMax([]int{3, 1, 2}, 2)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no trace output at info level, got %q", buf.String())
+	}
+}
+
+func TestRollingMinMax_TracingDisabledAllocs(t *testing.T) {
+	nums := []int{5, -3, 8, 8, 0, 2, -7, 6, 1, 9, 4, -2}
+
+	// Two deques with their buffers plus the two result slices
+	allocs := testing.AllocsPerRun(10, func() { RollingMinMax(nums, 3) })
+	if allocs > 6 {
+		t.Errorf("Expected at most 6 allocations with tracing off, got %v", allocs)
+	}
+}
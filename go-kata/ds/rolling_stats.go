@@ -0,0 +1,87 @@
+package ds
+
+import (
+	"errors"
+)
+
+// WindowStats describes one window of RollingStats. Start and End are the
+// inclusive indexes of the window in the input; MinIdx and MaxIdx locate the
+// extremes, preferring the latest index on ties. Sum, Mean and Variance
+// (population) are only filled in when moments are requested.
+type WindowStats struct {
+	Start  int
+	End    int
+	Min    int
+	Max    int
+	MinIdx int
+	MaxIdx int
+	Range  int
+
+	Sum      int
+	Mean     float64
+	Variance float64
+}
+
+// RollingStats is RollingMinMax with the extremes located and, optionally,
+// the running sum, mean and variance of every window. Moments are maintained
+// incrementally from the running sum and sum of squares, so each window costs
+// O(1) amortized either way.
+func RollingStats(nums []int, k int, moments bool) ([]WindowStats, error) {
+
+	if k <= 0 {
+		return nil, errors.New("Window size must be positive!!")
+	}
+
+	out := make([]WindowStats, 0, max(len(nums)-k+1, 1))
+	if len(nums) == 0 {
+		return out, nil
+	}
+
+	k = min(len(nums), k)
+
+	w, err := NewRollingWindow(k)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, sum_sq := 0, 0
+
+	for curr_idx, num := range nums {
+		minV, maxV, full := w.Push(num)
+
+		if moments {
+			sum += num
+			sum_sq += num * num
+			if curr_idx >= k {
+				expired := nums[curr_idx-k]
+				sum -= expired
+				sum_sq -= expired * expired
+			}
+		}
+
+		if !full {
+			continue
+		}
+
+		stats := WindowStats{
+			Start:  curr_idx - k + 1,
+			End:    curr_idx,
+			Min:    minV,
+			Max:    maxV,
+			MinIdx: w.ArgMin(),
+			MaxIdx: w.ArgMax(),
+			Range:  maxV - minV,
+		}
+
+		if moments {
+			n := float64(k)
+			stats.Sum = sum
+			stats.Mean = float64(sum) / n
+			stats.Variance = float64(k*sum_sq-sum*sum) / (n * n)
+		}
+
+		out = append(out, stats)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package ds
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRollingStats_Extrema(t *testing.T) {
+	tests := []struct {
+		name     string
+		nums     []int
+		k        int
+		expected []WindowStats
+	}{
+		{
+			name: "mixed sequence",
+			nums: []int{1, 3, 2, 5, 4},
+			k:    3,
+			expected: []WindowStats{
+				{Start: 0, End: 2, Min: 1, Max: 3, MinIdx: 0, MaxIdx: 1, Range: 2},
+				{Start: 1, End: 3, Min: 2, Max: 5, MinIdx: 2, MaxIdx: 3, Range: 3},
+				{Start: 2, End: 4, Min: 2, Max: 5, MinIdx: 2, MaxIdx: 3, Range: 3},
+			},
+		},
+		{
+			name: "ties prefer latest index",
+			nums: []int{2, 2, 2},
+			k:    2,
+			expected: []WindowStats{
+				{Start: 0, End: 1, Min: 2, Max: 2, MinIdx: 1, MaxIdx: 1, Range: 0},
+				{Start: 1, End: 2, Min: 2, Max: 2, MinIdx: 2, MaxIdx: 2, Range: 0},
+			},
+		},
+		{
+			name: "k larger than input",
+			nums: []int{4, -1},
+			k:    5,
+			expected: []WindowStats{
+				{Start: 0, End: 1, Min: -1, Max: 4, MinIdx: 1, MaxIdx: 0, Range: 5},
+			},
+		},
+		{
+			name:     "empty array",
+			nums:     []int{},
+			k:        3,
+			expected: []WindowStats{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RollingStats(tt.nums, tt.k, false)
+			if err != nil {
+				t.Errorf("RollingStats(%v, %d) returned error: %v", tt.nums, tt.k, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("RollingStats(%v, %d) = %+v, want %+v", tt.nums, tt.k, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRollingStats_Moments(t *testing.T) {
+	nums := []int{2, 4, 4, 4, 5, 5, 7, 9}
+	k := 4
+
+	got, err := RollingStats(nums, k, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(got) != len(nums)-k+1 {
+		t.Fatalf("Expected %d windows, got %d", len(nums)-k+1, len(got))
+	}
+
+	for _, s := range got {
+		window := nums[s.Start : s.End+1]
+
+		sum := 0
+		for _, v := range window {
+			sum += v
+		}
+		mean := float64(sum) / float64(k)
+
+		variance := 0.0
+		for _, v := range window {
+			variance += (float64(v) - mean) * (float64(v) - mean)
+		}
+		variance /= float64(k)
+
+		if s.Sum != sum {
+			t.Errorf("Window %d-%d: expected sum %d, got %d", s.Start, s.End, sum, s.Sum)
+		}
+		if math.Abs(s.Mean-mean) > 1e-9 {
+			t.Errorf("Window %d-%d: expected mean %v, got %v", s.Start, s.End, mean, s.Mean)
+		}
+		if math.Abs(s.Variance-variance) > 1e-9 {
+			t.Errorf("Window %d-%d: expected variance %v, got %v", s.Start, s.End, variance, s.Variance)
+		}
+		if nums[s.MinIdx] != s.Min || nums[s.MaxIdx] != s.Max {
+			t.Errorf("Window %d-%d: indexes do not point at min/max", s.Start, s.End)
+		}
+	}
+}
+
+func TestRollingStats_MatchesRollingMinMax(t *testing.T) {
+	nums := []int{5, -3, 8, 8, 0, 2, -7, 6, 1, 9, 4, -2}
+
+	for k := 1; k <= len(nums); k++ {
+		expectedMins, expectedMaxs, _ := RollingMinMax(nums, k)
+		got, _ := RollingStats(nums, k, false)
+
+		for i, s := range got {
+			if s.Min != expectedMins[i] || s.Max != expectedMaxs[i] {
+				t.Errorf("k=%d window %d: got min=%d max=%d, want min=%d max=%d",
+					k, i, s.Min, s.Max, expectedMins[i], expectedMaxs[i])
+			}
+		}
+	}
+}
+
+func TestRollingStats_InvalidWindow(t *testing.T) {
+	if _, err := RollingStats([]int{1, 2}, 0, false); err == nil {
+		t.Error("Expected error for window size 0")
+	}
+}
@@ -0,0 +1,175 @@
+package ds
+
+import (
+	"errors"
+)
+
+type MinMax struct {
+	Min int
+	Max int
+}
+
+// RollingWindow is the online form of RollingMinMax: values are pushed one at
+// a time and the min/max of the last k values is available as soon as k
+// values have been seen. Only the last k values are kept.
+type RollingWindow struct {
+	k    int
+	n    int   // values pushed so far (absolute index of the next value)
+	vals []int // vals[idx%k] holds the value at absolute index idx
+
+	maxQ *Deque // Monotonic ordering (absolute indexes)
+	minQ *Deque
+}
+
+func NewRollingWindow(k int) (*RollingWindow, error) {
+	if k <= 0 {
+		return nil, errors.New("Window size must be positive!!")
+	}
+
+	return &RollingWindow{
+		k:    k,
+		vals: make([]int, k),
+		maxQ: NewDeque(k),
+		minQ: NewDeque(k),
+	}, nil
+}
+
+func (w *RollingWindow) Full() bool { return w.n >= w.k }
+
+func (w *RollingWindow) value(idx int) int { return w.vals[idx%w.k] }
+
+func (w *RollingWindow) purge(Q *Deque) {
+	for !Q.Empty() {
+		front_idx, _ := Q.PeekFront()
+		if front_idx > w.n-w.k {
+			break
+		}
+		Q.PopFront()
+	}
+}
+
+func (w *RollingWindow) monotonicPush(Q *Deque, keep func(old, new int) bool) {
+	new_value := w.value(w.n)
+
+	for !Q.Empty() {
+		back_idx, _ := Q.PeekBack()
+		if keep(w.value(back_idx), new_value) {
+			break
+		}
+		Q.PopBack()
+	}
+
+	Q.PushBack(w.n)
+}
+
+// Push adds x to the window. Once the window holds k values it returns the
+// current min and max with ok set; before that ok is false.
+func (w *RollingWindow) Push(x int) (minV, maxV int, ok bool) {
+
+	// Drop any expired elements before x takes the oldest value's slot
+	w.purge(w.maxQ)
+	w.purge(w.minQ)
+
+	w.vals[w.n%w.k] = x
+
+	w.monotonicPush(w.maxQ, func(old, new int) bool { return old > new })
+	w.monotonicPush(w.minQ, func(old, new int) bool { return old < new })
+
+	w.n++
+
+	if !w.Full() {
+		return 0, 0, false
+	}
+
+	return w.Min(), w.Max(), true
+}
+
+// Min and Max report the extremes of the values pushed so far in the current
+// window, even if the window is not yet full. They return 0 when empty.
+func (w *RollingWindow) Min() int {
+	idx, err := w.minQ.PeekFront()
+	if err != nil {
+		return 0
+	}
+	return w.value(idx)
+}
+
+func (w *RollingWindow) Max() int {
+	idx, err := w.maxQ.PeekFront()
+	if err != nil {
+		return 0
+	}
+	return w.value(idx)
+}
+
+// ArgMin and ArgMax return the absolute index (count of values pushed before
+// it) of the current min and max, preferring the most recent on ties. They
+// return -1 when empty.
+func (w *RollingWindow) ArgMin() int {
+	idx, err := w.minQ.PeekFront()
+	if err != nil {
+		return -1
+	}
+	return idx
+}
+
+func (w *RollingWindow) ArgMax() int {
+	idx, err := w.maxQ.PeekFront()
+	if err != nil {
+		return -1
+	}
+	return idx
+}
+
+// RollingStream emits the min/max of every full window of k values read from
+// a Stream. Unlike RollingMinMax it cannot know the stream length up front,
+// so a stream shorter than k produces nothing.
+type RollingStream struct {
+	src Stream
+	w   *RollingWindow
+}
+
+func NewRollingStream(src Stream, k int) (*RollingStream, error) {
+	w, err := NewRollingWindow(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RollingStream{src: src, w: w}, nil
+}
+
+func (r *RollingStream) Next() (MinMax, bool) {
+	for {
+		x, ok := r.src.Next()
+		if !ok {
+			return MinMax{}, false
+		}
+
+		if minV, maxV, full := r.w.Push(x); full {
+			return MinMax{Min: minV, Max: maxV}, true
+		}
+	}
+}
+
+// RollingMinMaxChan is the channel form of RollingStream. The returned channel
+// is closed once in is closed.
+func RollingMinMaxChan(in <-chan int, k int) (<-chan MinMax, error) {
+	w, err := NewRollingWindow(k)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan MinMax)
+
+	go func() {
+		defer close(out)
+
+		for x := range in {
+			if minV, maxV, full := w.Push(x); full {
+				out <- MinMax{Min: minV, Max: maxV}
+			}
+		}
+	}()
+
+	return out, nil
+}
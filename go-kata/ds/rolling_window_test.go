@@ -0,0 +1,120 @@
+package ds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRollingWindow_MatchesRollingMinMax(t *testing.T) {
+	tests := []struct {
+		name string
+		nums []int
+		k    int
+	}{
+		{name: "simple increasing sequence", nums: []int{1, 2, 3, 4, 5}, k: 3},
+		{name: "simple decreasing sequence", nums: []int{5, 4, 3, 2, 1}, k: 3},
+		{name: "mixed sequence", nums: []int{1, 3, 2, 5, 4}, k: 3},
+		{name: "window size 1", nums: []int{1, 2, 3, 4, 5}, k: 1},
+		{name: "duplicate elements", nums: []int{2, 2, 2, 2, 2}, k: 3},
+		{name: "mixed positive and negative", nums: []int{-1, 2, -3, 4, -5, 6, 0}, k: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectedMins, expectedMaxs, _ := RollingMinMax(tt.nums, tt.k)
+
+			w, err := NewRollingWindow(tt.k)
+			if err != nil {
+				t.Fatalf("NewRollingWindow(%d) returned error: %v", tt.k, err)
+			}
+
+			mins := make([]int, 0)
+			maxs := make([]int, 0)
+			for _, x := range tt.nums {
+				if minV, maxV, ok := w.Push(x); ok {
+					mins = append(mins, minV)
+					maxs = append(maxs, maxV)
+				}
+			}
+
+			if !reflect.DeepEqual(mins, expectedMins) {
+				t.Errorf("RollingWindow(%v, %d) mins = %v, want %v", tt.nums, tt.k, mins, expectedMins)
+			}
+			if !reflect.DeepEqual(maxs, expectedMaxs) {
+				t.Errorf("RollingWindow(%v, %d) maxs = %v, want %v", tt.nums, tt.k, maxs, expectedMaxs)
+			}
+		})
+	}
+}
+
+func TestRollingWindow_PartialWindow(t *testing.T) {
+	w, _ := NewRollingWindow(3)
+
+	if _, _, ok := w.Push(5); ok {
+		t.Error("Expected ok=false before the window is full")
+	}
+	if _, _, ok := w.Push(1); ok {
+		t.Error("Expected ok=false before the window is full")
+	}
+
+	if w.Min() != 1 || w.Max() != 5 {
+		t.Errorf("Expected partial min=1 max=5, got min=%d max=%d", w.Min(), w.Max())
+	}
+
+	minV, maxV, ok := w.Push(3)
+	if !ok || minV != 1 || maxV != 5 {
+		t.Errorf("Expected (1, 5, true), got (%d, %d, %t)", minV, maxV, ok)
+	}
+}
+
+func TestRollingWindow_InvalidSize(t *testing.T) {
+	if _, err := NewRollingWindow(0); err == nil {
+		t.Error("Expected error for window size 0")
+	}
+}
+
+func TestRollingStream(t *testing.T) {
+	r, err := NewRollingStream(NewMockStream([]int{4, 2, 12, 3, 8, 7}), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []MinMax
+	for {
+		mm, ok := r.Next()
+		if !ok {
+			break
+		}
+		got = append(got, mm)
+	}
+
+	expected := []MinMax{{2, 12}, {2, 12}, {3, 12}, {3, 8}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestRollingMinMaxChan(t *testing.T) {
+	in := make(chan int)
+	out, err := RollingMinMaxChan(in, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	go func() {
+		for _, x := range []int{3, 1, 4, 1, 5} {
+			in <- x
+		}
+		close(in)
+	}()
+
+	var got []MinMax
+	for mm := range out {
+		got = append(got, mm)
+	}
+
+	expected := []MinMax{{1, 3}, {1, 4}, {1, 4}, {1, 5}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package schedhttp serves a JSON control plane for a ds.SyncScheduler.
+package schedhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-kata/ds"
+)
+
+// Handler serves a SyncScheduler over HTTP:
+//
+//	GET    /tasks           ready tasks in deadline order
+//	GET    /snapshot        everything the scheduler holds
+//	GET    /tasks/{id}      one task
+//	PUT    /tasks/{id}      add or update a task
+//	DELETE /tasks/{id}      remove a task
+//	POST   /tasks/{id}/run  make a task due now
+//
+// Mount it under a prefix with http.StripPrefix.
+type Handler struct {
+	s   *ds.SyncScheduler
+	mux *http.ServeMux
+}
+
+// TaskRequest is the body of PUT /tasks/{id}. Exactly one of Deadline and
+// After sets when the task is due; After is a Go duration such as "90s".
+// Priority, Payload and Labels are left alone on update when omitted.
+type TaskRequest struct {
+	Deadline *time.Time        `json:"deadline,omitempty"`
+	After    string            `json:"after,omitempty"`
+	Priority *int              `json:"priority,omitempty"`
+	Payload  json.RawMessage   `json:"payload,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+func NewHandler(s *ds.SyncScheduler) *Handler {
+	h := &Handler{s: s, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("GET /tasks", h.list)
+	h.mux.HandleFunc("GET /snapshot", h.snapshot)
+	h.mux.HandleFunc("GET /tasks/{id}", h.get)
+	h.mux.HandleFunc("PUT /tasks/{id}", h.put)
+	h.mux.HandleFunc("DELETE /tasks/{id}", h.remove)
+	h.mux.HandleFunc("POST /tasks/{id}/run", h.run)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+var errTaskNotFound = errors.New("Task not found!!")
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.s.Tasks())
+}
+
+func (h *Handler) snapshot(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.s.Snapshot())
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	t, ok := h.s.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errTaskNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req TaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var opts []ds.TaskOption
+	if req.Priority != nil {
+		opts = append(opts, ds.WithPriority(*req.Priority))
+	}
+	if req.Payload != nil {
+		opts = append(opts, ds.WithPayload(req.Payload))
+	}
+	if req.Labels != nil {
+		opts = append(opts, ds.WithLabels(req.Labels))
+	}
+
+	var (
+		after time.Duration
+		err   error
+	)
+	switch {
+	case req.Deadline != nil && req.After != "":
+		writeError(w, http.StatusBadRequest, errors.New("Give either deadline or after, not both!!"))
+		return
+	case req.Deadline == nil && req.After == "":
+		writeError(w, http.StatusBadRequest, errors.New("Missing deadline or after!!"))
+		return
+	case req.After != "":
+		if after, err = time.ParseDuration(req.After); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var t *ds.Task
+	h.s.Do(func(s *ds.Scheduler) {
+		if req.Deadline != nil {
+			s.AddOrUpdate(id, *req.Deadline, opts...)
+		} else {
+			s.AddOrUpdateAfter(id, after, opts...)
+		}
+		t, _ = s.Get(id)
+	})
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *Handler) remove(w http.ResponseWriter, r *http.Request) {
+	if !h.s.Remove(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, errTaskNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) run(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var t *ds.Task
+	h.s.Do(func(s *ds.Scheduler) {
+		if s.RunNow(id) {
+			t, _ = s.Get(id)
+		}
+	})
+
+	if t == nil {
+		writeError(w, http.StatusNotFound, errTaskNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,202 @@
+package schedhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-kata/ds"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ids(tasks []*ds.Task) []string {
+	out := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		out = append(out, t.ID)
+	}
+	return out
+}
+
+func newTestHandler() (*Handler, *ds.SyncScheduler, *ds.FakeClock) {
+	clock := ds.NewFakeClock(epoch)
+	s := ds.NewSyncSchedulerWithClock(clock)
+	return NewHandler(s), s, clock
+}
+
+func serve(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeTask(t *testing.T, rec *httptest.ResponseRecorder) *ds.Task {
+	t.Helper()
+
+	var task ds.Task
+	if err := json.NewDecoder(rec.Body).Decode(&task); err != nil {
+		t.Fatalf("Failed to decode task: %v", err)
+	}
+	return &task
+}
+
+func TestHandlerPutAndGet(t *testing.T) {
+	h, _, _ := newTestHandler()
+
+	rec := serve(h, "PUT", "/tasks/report", `{"deadline":"2024-01-01T01:00:00Z","priority":3,"payload":{"to":"ops"},"labels":{"team":"billing"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	task := decodeTask(t, rec)
+	if task.ID != "report" || task.Priority() != 3 || !task.Deadline().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	rec = serve(h, "GET", "/tasks/report", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	task = decodeTask(t, rec)
+	if task.Labels["team"] != "billing" || string(task.Payload.(json.RawMessage)) != `{"to":"ops"}` {
+		t.Errorf("Expected labels and payload to round-trip, got %v and %s", task.Labels, task.Payload)
+	}
+	if rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON content type, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	// An update that only moves the deadline keeps the rest
+	rec = serve(h, "PUT", "/tasks/report", `{"after":"10m"}`)
+	task = decodeTask(t, rec)
+	if !task.Deadline().Equal(epoch.Add(10*time.Minute)) || task.Priority() != 3 {
+		t.Errorf("Expected deadline +10m with priority 3, got %v and %d", task.Deadline(), task.Priority())
+	}
+}
+
+func TestHandlerBadRequests(t *testing.T) {
+	h, _, _ := newTestHandler()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{"deadline":`},
+		{"missing when", `{"priority":1}`},
+		{"both", `{"deadline":"2024-01-01T00:00:00Z","after":"1s"}`},
+		{"bad duration", `{"after":"soon"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, "PUT", "/tasks/x", tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400, got %d", rec.Code)
+			}
+
+			var body map[string]string
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body["error"] == "" {
+				t.Error("Expected an error message")
+			}
+		})
+	}
+}
+
+func TestHandlerList(t *testing.T) {
+	h, s, _ := newTestHandler()
+
+	s.AddOrUpdate("b", epoch.Add(2*time.Minute))
+	s.AddOrUpdate("a", epoch.Add(time.Minute))
+	s.AddOrUpdate("c", epoch.Add(3*time.Minute))
+
+	rec := serve(h, "GET", "/tasks", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var tasks []*ds.Task
+	if err := json.NewDecoder(rec.Body).Decode(&tasks); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(tasks); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Expected [a b c], got %v", got)
+	}
+
+	rec = serve(h, "GET", "/snapshot", "")
+	var snap ds.SchedulerSnapshot
+	if err := json.NewDecoder(rec.Body).Decode(&snap); err != nil || len(snap.Ready) != 3 {
+		t.Errorf("Expected a snapshot with 3 ready tasks, got %+v, %v", snap, err)
+	}
+}
+
+func TestHandlerRemove(t *testing.T) {
+	h, s, _ := newTestHandler()
+
+	s.AddOrUpdate("a", epoch)
+
+	if rec := serve(h, "DELETE", "/tasks/a", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	if rec := serve(h, "DELETE", "/tasks/a", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a second delete, got %d", rec.Code)
+	}
+	if rec := serve(h, "GET", "/tasks/a", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestHandlerRunNow(t *testing.T) {
+	h, s, clock := newTestHandler()
+
+	s.AddOrUpdate("later", epoch.Add(time.Hour), ds.WithPriority(2))
+	clock.Advance(time.Minute)
+
+	rec := serve(h, "POST", "/tasks/later/run", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if task := decodeTask(t, rec); !task.Deadline().Equal(epoch.Add(time.Minute)) || task.Priority() != 2 {
+		t.Errorf("Expected the task due now with priority 2, got %v and %d", task.Deadline(), task.Priority())
+	}
+
+	if due := s.PopDueNow(); len(due) != 1 || due[0].ID != "later" {
+		t.Errorf("Expected later to be due, got %v", ids(due))
+	}
+
+	if rec := serve(h, "POST", "/tasks/missing/run", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHandlerMethodNotAllowed(t *testing.T) {
+	h, _, _ := newTestHandler()
+
+	if rec := serve(h, "POST", "/tasks/a", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHandlerPutRacingPop(t *testing.T) {
+	h, s, _ := newTestHandler()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			s.PopDueNow()
+		}
+	}()
+
+	for i := 0; i < 500; i++ {
+		rec := serve(h, "PUT", "/tasks/a", `{"after":"0s"}`)
+		if body := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || body == "null" {
+			t.Fatalf("Expected the task back, got %d %s", rec.Code, body)
+		}
+	}
+	<-done
+}
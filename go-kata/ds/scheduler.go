@@ -1,37 +1,42 @@
 package ds
 
 import (
-	"container/heap"
+	"sync/atomic"
 	"time"
 )
 
 type Task struct {
-	ID       string
-	deadline time.Time
+	ID      string
+	Payload any
+	Labels  map[string]string
 
-	index int
+	deadline time.Time
+	priority int
+	created  time.Time
+	updated  time.Time
+	attempts int
+	lastErr  error
+
+	seq   uint64 // queueing order, the last tie-break
+	recur *recurrence
 }
 
+// TaskHeap is a container/heap of tasks in the order PopDue hands them out.
+//
+// Deprecated: Scheduler keeps its tasks in a PriorityMap, which can also
+// find, update and remove a task by ID.
 type TaskHeap []*Task
 
-type Scheduler struct {
-	h    TaskHeap
-	byID map[string]*Task
-}
-
 func (h TaskHeap) Len() int {
 	return len(h)
 }
 
 func (h TaskHeap) Less(i, j int) bool {
-	return h[i].deadline.Before(h[j].deadline)
+	return h[i].before(h[j])
 }
 
 func (h TaskHeap) Swap(i, j int) {
 	h[i], h[j] = h[j], h[i]
-
-	h[i].index = i
-	h[j].index = j
 }
 
 func (h *TaskHeap) Push(x any) {
@@ -47,66 +52,250 @@ func (h *TaskHeap) Pop() any {
 	return to_remove
 }
 
+// TaskQueue is the API shared by Scheduler and the structures that can stand
+// in for it.
+type TaskQueue interface {
+	AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption)
+	Remove(ID string) bool
+	PopDue(deadline time.Time) []*Task
+	PopDueN(deadline time.Time, limit int) []*Task
+}
+
+var (
+	_ TaskQueue = (*Scheduler)(nil)
+	_ TaskQueue = (*SyncScheduler)(nil)
+	_ TaskQueue = (*ShardedScheduler)(nil)
+	_ TaskQueue = (*TimingWheel)(nil)
+)
+
+type Scheduler struct {
+	ready *PriorityMap[string, *Task] // tasks not waiting on prerequisites
+	byID  map[string]*Task
+	clock Clock
+	seq   *atomic.Uint64 // shared by the shards of a ShardedScheduler
+
+	waitingOn map[string]map[string]struct{} // ID -> unfinished prerequisites
+	waiters   map[string]map[string]struct{} // prerequisite -> IDs waiting on it
+	completed map[string]uint64              // ID -> its completion number in doneLog
+	doneLog   []completion                   // oldest first, for forgetting
+	doneCount uint64
+	retention int
+
+	retry    *RetryPolicy
+	inflight map[string]*Task
+	dead     map[string]*Task
+	leases   *Scheduler // lease expiry -> *lease, created by the first Claim
+
+	limiter Limiter
+	metrics Metrics
+}
+
+// before orders tasks by deadline, then by higher priority, then by the
+// order they were queued in. The order is total, so PopDue and every
+// ordered view of the same tasks agree even on ties.
+func (t *Task) before(other *Task) bool {
+	if !t.deadline.Equal(other.deadline) {
+		return t.deadline.Before(other.deadline)
+	}
+	if t.priority != other.priority {
+		return t.priority > other.priority
+	}
+	return t.seq < other.seq
+}
+
+// stamp gives t the next queueing sequence number.
+func (s *Scheduler) stamp(t *Task) {
+	t.seq = s.seq.Add(1)
+}
+
 func CreateScheduler() *Scheduler {
+	return CreateSchedulerWithClock(RealClock{})
+}
+
+func CreateSchedulerWithClock(clock Clock) *Scheduler {
 	return &Scheduler{
-		h:    make(TaskHeap, 0),
-		byID: make(map[string]*Task, 0),
+		ready: NewPriorityMap[string]((*Task).before),
+		byID:  make(map[string]*Task, 0),
+		clock: clock,
+		seq:   new(atomic.Uint64),
+
+		waitingOn: make(map[string]map[string]struct{}),
+		waiters:   make(map[string]map[string]struct{}),
+		completed: make(map[string]uint64),
+		retention: DefaultCompletionRetention,
+
+		inflight: make(map[string]*Task),
+		dead:     make(map[string]*Task),
 	}
 }
 
-func (s *Scheduler) AddOrUpdate(ID string, deadline time.Time) {
+// AddOrUpdateAfter schedules ID to be due d from the scheduler's clock.
+func (s *Scheduler) AddOrUpdateAfter(ID string, d time.Duration, opts ...TaskOption) {
+	s.AddOrUpdate(ID, s.clock.Now().Add(d), opts...)
+}
+
+// AddOrUpdate schedules ID at deadline. Updating an existing task keeps its
+// payload, priority and labels unless opts replace them.
+func (s *Scheduler) AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption) {
+
+	now := s.clock.Now()
 
 	if t, ok := s.byID[ID]; ok {
 
 		t.ID = ID
 		t.deadline = deadline
+		t.updated = now
+		t.recur = nil
+		for _, opt := range opts {
+			opt(t)
+		}
+
+		// A blocked task is not on the heap
+		if _, ok := s.ready.Get(ID); ok {
+			s.ready.Set(ID, t)
+		}
 
-		heap.Fix(&s.h, t.index)
+		if s.metrics != nil {
+			s.metrics.TaskUpdated()
+		}
 
 		return
 	}
 
-	index_to_insert := len(s.h)
+	// Scheduling an ID again means it has not run yet
+	delete(s.completed, ID)
+
 	task := &Task{
 		ID:       ID,
 		deadline: deadline,
-		index:    index_to_insert,
+		created:  now,
+		updated:  now,
+	}
+	for _, opt := range opts {
+		opt(task)
 	}
+	s.stamp(task)
 	s.byID[ID] = task
 
-	heap.Push(&s.h, task)
+	if s.metrics != nil {
+		s.metrics.TaskAdded()
+		defer s.reportDepth()
+	}
+
+	if s.blocked(ID) {
+		return
+	}
+
+	s.ready.Set(ID, task)
 
 }
 
 func (s *Scheduler) Remove(ID string) bool {
 
-	t, ok := s.byID[ID]
-	if !ok {
+	if _, ok := s.byID[ID]; !ok {
 		return false
 	}
 
-	heap.Remove(&s.h, t.index)
+	s.ready.Delete(ID)
 	delete(s.byID, ID)
+	s.dropPrerequisites(ID)
+
+	if s.metrics != nil {
+		s.metrics.TaskRemoved()
+		s.reportDepth()
+	}
 
 	return true
 }
 
+// head returns the task PopDue would hand out next, without popping it.
+func (s *Scheduler) head() (*Task, bool) {
+	_, t, ok := s.ready.PeekMin()
+	return t, ok
+}
+
+// PopDue pops every task whose deadline is at or before the given time, in
+// deadline order.
 func (s *Scheduler) PopDue(deadline time.Time) []*Task {
+	return s.PopDueN(deadline, 0)
+}
+
+// PopDueN is PopDue capped at limit tasks; limit <= 0 means no cap beyond
+// the limiter's, if one is set. Due tasks beyond the limit stay queued for
+// the next call.
+func (s *Scheduler) PopDueN(deadline time.Time, limit int) []*Task {
 
 	var due []*Task = make([]*Task, 0)
 
-	if len(s.h) > 0 {
-		t := s.h[0]
+	// Leases expire by the clock; deadline may be ahead of it
+	s.ReclaimExpired(s.clock.Now())
 
-		if t.deadline.After(deadline) {
+	if s.limiter != nil {
+		now := s.clock.Now()
+		permits := s.limiter.Available(now)
+		if permits <= 0 {
 			return due
 		}
+		if limit <= 0 || limit > permits {
+			limit = permits
+		}
+		defer func() { s.limiter.Consume(now, len(due)) }()
+	}
+
+	for limit <= 0 || len(due) < limit {
+		t, ok := s.head()
+
+		if !ok || t.deadline.After(deadline) {
+			break
+		}
 
-		dueTask := heap.Pop(&s.h).(*Task)
-		delete(s.byID, dueTask.ID)
+		if dueTask, ok := s.popHead(deadline); ok {
+			due = append(due, dueTask)
+		}
+	}
 
-		due = append(due, dueTask)
+	if s.metrics != nil && len(due) > 0 {
+		now := s.clock.Now()
+		for _, t := range due {
+			s.metrics.TaskPopped(max(now.Sub(t.deadline), 0))
+		}
+		s.reportDepth()
 	}
 
 	return due
 }
+
+// popHead pops the task at the root of the heap, which the caller has
+// checked is due at deadline. It returns false for a recurring occurrence
+// that is skipped rather than handed out.
+func (s *Scheduler) popHead(deadline time.Time) (*Task, bool) {
+
+	_, t, _ := s.ready.PopMin()
+	delete(s.byID, t.ID)
+
+	if t.recur != nil {
+		return s.popRecurring(t, deadline)
+	}
+
+	if s.retry != nil {
+		s.inflight[t.ID] = t
+	}
+
+	return t, true
+}
+
+// PopDueNow is PopDue at the scheduler's current clock time.
+func (s *Scheduler) PopDueNow() []*Task {
+	return s.PopDue(s.clock.Now())
+}
+
+// PopOne pops only the earliest task, if it is due.
+func (s *Scheduler) PopOne(deadline time.Time) (*Task, bool) {
+
+	due := s.PopDueN(deadline, 1)
+	if len(due) == 0 {
+		return nil, false
+	}
+
+	return due[0], true
+}
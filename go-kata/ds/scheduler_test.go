@@ -1,6 +1,9 @@
 package ds
 
 import (
+	"container/heap"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 )
@@ -12,16 +15,16 @@ func TestCreateScheduler(t *testing.T) {
 		t.Fatal("CreateScheduler() returned nil")
 	}
 
-	if s.h == nil {
-		t.Error("Scheduler heap should be initialized")
+	if _, ok := s.NextDeadline(); ok {
+		t.Error("Expected no next deadline on an empty scheduler")
 	}
 
 	if s.byID == nil {
 		t.Error("Scheduler byID map should be initialized")
 	}
 
-	if len(s.h) != 0 {
-		t.Errorf("Expected empty heap, got length %d", len(s.h))
+	if s.Len() != 0 {
+		t.Errorf("Expected empty heap, got length %d", s.Len())
 	}
 
 	if len(s.byID) != 0 {
@@ -36,8 +39,8 @@ func TestAddOrUpdateNewTask(t *testing.T) {
 
 	s.AddOrUpdate("task1", deadline)
 
-	if len(s.h) != 1 {
-		t.Errorf("Expected heap length 1, got %d", len(s.h))
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1, got %d", s.Len())
 	}
 
 	if len(s.byID) != 1 {
@@ -57,8 +60,8 @@ func TestAddOrUpdateNewTask(t *testing.T) {
 		t.Errorf("Expected deadline %v, got %v", deadline, task.deadline)
 	}
 
-	if task.index != 0 {
-		t.Errorf("Expected task index 0, got %d", task.index)
+	if next, ok := s.NextDeadline(); !ok || !next.Equal(deadline) {
+		t.Errorf("Expected task1 to be next at %v, got %v", deadline, next)
 	}
 }
 
@@ -75,8 +78,8 @@ func TestAddOrUpdateExistingTask(t *testing.T) {
 	s.AddOrUpdate("task1", newDeadline)
 
 	// Should still have only one task
-	if len(s.h) != 1 {
-		t.Errorf("Expected heap length 1, got %d", len(s.h))
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1, got %d", s.Len())
 	}
 
 	if len(s.byID) != 1 {
@@ -110,8 +113,8 @@ func TestAddMultipleTasks(t *testing.T) {
 		s.AddOrUpdate(task.id, task.deadline)
 	}
 
-	if len(s.h) != 3 {
-		t.Errorf("Expected heap length 3, got %d", len(s.h))
+	if s.Len() != 3 {
+		t.Errorf("Expected heap length 3, got %d", s.Len())
 	}
 
 	if len(s.byID) != 3 {
@@ -119,8 +122,8 @@ func TestAddMultipleTasks(t *testing.T) {
 	}
 
 	// The heap should maintain min-heap property (earliest deadline at top)
-	if s.h[0].ID != "task2" {
-		t.Errorf("Expected task2 at heap top, got %s", s.h[0].ID)
+	if root(s).ID != "task2" {
+		t.Errorf("Expected task2 at heap top, got %s", root(s).ID)
 	}
 }
 
@@ -137,8 +140,8 @@ func TestRemoveExistingTask(t *testing.T) {
 		t.Error("Remove should return true for existing task")
 	}
 
-	if len(s.h) != 1 {
-		t.Errorf("Expected heap length 1 after removal, got %d", len(s.h))
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1 after removal, got %d", s.Len())
 	}
 
 	if len(s.byID) != 1 {
@@ -151,8 +154,8 @@ func TestRemoveExistingTask(t *testing.T) {
 	}
 
 	// Remaining task should be task2
-	if s.h[0].ID != "task2" {
-		t.Errorf("Expected task2 to remain, got %s", s.h[0].ID)
+	if root(s).ID != "task2" {
+		t.Errorf("Expected task2 to remain, got %s", root(s).ID)
 	}
 }
 
@@ -168,8 +171,8 @@ func TestRemoveNonExistentTask(t *testing.T) {
 		t.Error("Remove should return false for non-existent task")
 	}
 
-	if len(s.h) != 1 {
-		t.Errorf("Expected heap length 1, got %d", len(s.h))
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1, got %d", s.Len())
 	}
 
 	if len(s.byID) != 1 {
@@ -209,8 +212,8 @@ func TestPopDueWithDueTask(t *testing.T) {
 	}
 
 	// Task should be removed from scheduler
-	if len(s.h) != 0 {
-		t.Errorf("Expected empty heap after popping due task, got length %d", len(s.h))
+	if s.Len() != 0 {
+		t.Errorf("Expected empty heap after popping due task, got length %d", s.Len())
 	}
 
 	if len(s.byID) != 0 {
@@ -232,8 +235,8 @@ func TestPopDueWithNoDueTasks(t *testing.T) {
 	}
 
 	// Task should remain in scheduler
-	if len(s.h) != 1 {
-		t.Errorf("Expected heap length 1, got %d", len(s.h))
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1, got %d", s.Len())
 	}
 
 	if len(s.byID) != 1 {
@@ -252,34 +255,106 @@ func TestPopDueFromEmptyScheduler(t *testing.T) {
 	}
 }
 
-func TestPopDueOnlyPopsEarliestTask(t *testing.T) {
+func TestPopDueDrainsAllDueTasks(t *testing.T) {
 	s := CreateScheduler()
 	now := time.Now()
 
 	// Add multiple tasks, some due, some not
-	s.AddOrUpdate("task1", now.Add(-2*time.Hour)) // Due (earliest)
 	s.AddOrUpdate("task2", now.Add(-1*time.Hour)) // Due (later)
 	s.AddOrUpdate("task3", now.Add(time.Hour))    // Not due
+	s.AddOrUpdate("task1", now.Add(-2*time.Hour)) // Due (earliest)
+	s.AddOrUpdate("task4", now)                   // Due (exactly at deadline)
 
 	due := s.PopDue(now)
 
-	// PopDue should only return one task (the earliest due task)
-	if len(due) != 1 {
-		t.Errorf("Expected 1 due task, got %d", len(due))
+	// PopDue should return every due task in deadline order
+	if len(due) != 3 {
+		t.Fatalf("Expected 3 due tasks, got %d", len(due))
 	}
 
-	if due[0].ID != "task1" {
-		t.Errorf("Expected task1 (earliest) to be popped, got %s", due[0].ID)
+	for i, expected := range []string{"task1", "task2", "task4"} {
+		if due[i].ID != expected {
+			t.Errorf("Expected %s at position %d, got %s", expected, i, due[i].ID)
+		}
+	}
+
+	// Only the future task should remain
+	if s.Len() != 1 {
+		t.Errorf("Expected heap length 1 after draining due tasks, got %d", s.Len())
+	}
+
+	if len(s.byID) != 1 {
+		t.Errorf("Expected byID map length 1 after draining due tasks, got %d", len(s.byID))
+	}
+
+	if _, exists := s.byID["task3"]; !exists {
+		t.Error("Future task should still be scheduled")
+	}
+}
+
+func TestPopDueNLimitsBatch(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	for i, id := range []string{"a", "b", "c", "d"} {
+		s.AddOrUpdate(id, now.Add(-time.Duration(4-i)*time.Minute))
+	}
+
+	due := s.PopDueN(now, 3)
+	if len(due) != 3 {
+		t.Fatalf("Expected 3 due tasks, got %d", len(due))
+	}
+
+	if due[0].ID != "a" || due[2].ID != "c" {
+		t.Errorf("Expected batch [a b c], got [%s %s %s]", due[0].ID, due[1].ID, due[2].ID)
+	}
+
+	// The rest of the backlog comes out on the next call
+	due = s.PopDueN(now, 3)
+	if len(due) != 1 || due[0].ID != "d" {
+		t.Errorf("Expected remaining task d, got %d tasks", len(due))
+	}
+
+	// A non-positive limit means no cap
+	s.AddOrUpdate("e", now)
+	s.AddOrUpdate("f", now)
+	if due := s.PopDueN(now, 0); len(due) != 2 {
+		t.Errorf("Expected 2 due tasks with no cap, got %d", len(due))
+	}
+}
+
+func TestPopOneOnlyPopsEarliestTask(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("task1", now.Add(-2*time.Hour)) // Due (earliest)
+	s.AddOrUpdate("task2", now.Add(-1*time.Hour)) // Due (later)
+	s.AddOrUpdate("task3", now.Add(time.Hour))    // Not due
+
+	task, ok := s.PopOne(now)
+	if !ok {
+		t.Fatal("Expected a due task")
+	}
+
+	if task.ID != "task1" {
+		t.Errorf("Expected task1 (earliest) to be popped, got %s", task.ID)
 	}
 
 	// Scheduler should still have 2 tasks
-	if len(s.h) != 2 {
-		t.Errorf("Expected heap length 2 after popping one task, got %d", len(s.h))
+	if s.Len() != 2 {
+		t.Errorf("Expected heap length 2 after popping one task, got %d", s.Len())
 	}
 
 	if len(s.byID) != 2 {
 		t.Errorf("Expected byID map length 2 after popping one task, got %d", len(s.byID))
 	}
+
+	s.PopOne(now)
+
+	// Only the future task is left, and it is not due
+	if _, ok := s.PopOne(now); ok {
+		t.Error("Expected no due task")
+	}
 }
 
 func TestComplexScenario(t *testing.T) {
@@ -301,35 +376,29 @@ func TestComplexScenario(t *testing.T) {
 	}
 
 	// Now we should have 2 tasks, both overdue
-	if len(s.h) != 2 {
-		t.Errorf("Expected heap length 2, got %d", len(s.h))
+	if s.Len() != 2 {
+		t.Errorf("Expected heap length 2, got %d", s.Len())
 	}
 
-	// Pop due tasks - should get the most urgent first
-	due1 := s.PopDue(now)
-	if len(due1) != 1 {
-		t.Errorf("Expected 1 due task, got %d", len(due1))
+	// Pop due tasks - should get both, most urgent first
+	due := s.PopDue(now)
+	if len(due) != 2 {
+		t.Fatalf("Expected 2 due tasks, got %d", len(due))
 	}
 
 	// The most urgent should be the one with earlier deadline
 	expectedFirst := "urgent" // -1 hour is earlier than -30 minutes
-	if due1[0].ID != expectedFirst {
-		t.Errorf("Expected %s to be popped first, got %s", expectedFirst, due1[0].ID)
-	}
-
-	// Pop again - should get the second overdue task
-	due2 := s.PopDue(now)
-	if len(due2) != 1 {
-		t.Errorf("Expected 1 due task, got %d", len(due2))
+	if due[0].ID != expectedFirst {
+		t.Errorf("Expected %s to be popped first, got %s", expectedFirst, due[0].ID)
 	}
 
-	if due2[0].ID != "medium" {
-		t.Errorf("Expected 'medium' to be popped second, got %s", due2[0].ID)
+	if due[1].ID != "medium" {
+		t.Errorf("Expected 'medium' to be popped second, got %s", due[1].ID)
 	}
 
 	// Scheduler should now be empty
-	if len(s.h) != 0 {
-		t.Errorf("Expected empty heap, got length %d", len(s.h))
+	if s.Len() != 0 {
+		t.Errorf("Expected empty heap, got length %d", s.Len())
 	}
 
 	if len(s.byID) != 0 {
@@ -338,6 +407,32 @@ func TestComplexScenario(t *testing.T) {
 }
 
 func TestTaskHeapInterface(t *testing.T) {
+	now := time.Now()
+
+	h := &TaskHeap{}
+	for i, d := range []time.Duration{3, 1, 2} {
+		heap.Push(h, &Task{ID: fmt.Sprintf("task%d", d), deadline: now.Add(d * time.Hour), seq: uint64(i)})
+	}
+
+	if h.Len() != 3 {
+		t.Errorf("Expected heap length 3, got %d", h.Len())
+	}
+
+	// The root should be task1 (earliest deadline)
+	if (*h)[0].ID != "task1" {
+		t.Errorf("Expected task1 at root, got %s", (*h)[0].ID)
+	}
+
+	var popped []string
+	for h.Len() > 0 {
+		popped = append(popped, heap.Pop(h).(*Task).ID)
+	}
+	if !slices.Equal(popped, []string{"task1", "task2", "task3"}) {
+		t.Errorf("Expected deadline order, got %v", popped)
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
 	s := CreateScheduler()
 	now := time.Now()
 
@@ -355,23 +450,22 @@ func TestTaskHeapInterface(t *testing.T) {
 		s.AddOrUpdate(task.id, task.deadline)
 	}
 
-	// Test that heap maintains min-heap property
-	heap := s.h
-
-	// Test Len
-	if heap.Len() != 3 {
-		t.Errorf("Expected heap length 3, got %d", heap.Len())
+	if s.Len() != 3 {
+		t.Errorf("Expected 3 tasks, got %d", s.Len())
 	}
 
-	// Test Less - earlier deadline should be "less"
-	if !heap.Less(0, 1) && !heap.Less(0, 2) {
-		t.Error("Root should have earliest deadline")
+	// The root should be task1 (earliest deadline)
+	if got := ids(slices.Collect(s.All())); !slices.Equal(got, []string{"task1", "task2", "task3"}) {
+		t.Errorf("Expected [task1 task2 task3], got %v", got)
 	}
+}
 
-	// The root should be task1 (earliest deadline)
-	if heap[0].ID != "task1" {
-		t.Errorf("Expected task1 at root, got %s", heap[0].ID)
+// root returns the task PopDue would hand out next.
+func root(s *Scheduler) *Task {
+	for t := range s.All() {
+		return t
 	}
+	return nil
 }
 
 func TestConcurrentDeadlines(t *testing.T) {
@@ -384,8 +478,8 @@ func TestConcurrentDeadlines(t *testing.T) {
 	s.AddOrUpdate("task2", sameDeadline)
 	s.AddOrUpdate("task3", sameDeadline)
 
-	if len(s.h) != 3 {
-		t.Errorf("Expected heap length 3, got %d", len(s.h))
+	if s.Len() != 3 {
+		t.Errorf("Expected heap length 3, got %d", s.Len())
 	}
 
 	// All tasks should be considered not due if deadline is in future
@@ -396,8 +490,8 @@ func TestConcurrentDeadlines(t *testing.T) {
 
 	// All tasks should be considered due if deadline is in past
 	due = s.PopDue(sameDeadline.Add(time.Minute))
-	if len(due) != 1 {
-		t.Errorf("Expected 1 due task, got %d", len(due))
+	if len(due) != 3 {
+		t.Errorf("Expected 3 due tasks, got %d", len(due))
 	}
 }
 
@@ -411,16 +505,16 @@ func TestUpdateTaskToEarlierDeadline(t *testing.T) {
 	s.AddOrUpdate("task3", now.Add(1*time.Hour))
 
 	// task3 should be at the root
-	if s.h[0].ID != "task3" {
-		t.Errorf("Expected task3 at root, got %s", s.h[0].ID)
+	if root(s).ID != "task3" {
+		t.Errorf("Expected task3 at root, got %s", root(s).ID)
 	}
 
 	// Update task1 to have the earliest deadline
 	s.AddOrUpdate("task1", now.Add(30*time.Minute))
 
 	// Now task1 should be at the root
-	if s.h[0].ID != "task1" {
-		t.Errorf("Expected task1 at root after update, got %s", s.h[0].ID)
+	if root(s).ID != "task1" {
+		t.Errorf("Expected task1 at root after update, got %s", root(s).ID)
 	}
 }
 
@@ -434,15 +528,39 @@ func TestUpdateTaskToLaterDeadline(t *testing.T) {
 	s.AddOrUpdate("task3", now.Add(3*time.Hour))
 
 	// task1 should be at the root
-	if s.h[0].ID != "task1" {
-		t.Errorf("Expected task1 at root, got %s", s.h[0].ID)
+	if root(s).ID != "task1" {
+		t.Errorf("Expected task1 at root, got %s", root(s).ID)
 	}
 
 	// Update task1 to have the latest deadline
 	s.AddOrUpdate("task1", now.Add(4*time.Hour))
 
 	// Now task2 should be at the root
-	if s.h[0].ID != "task2" {
-		t.Errorf("Expected task2 at root after update, got %s", s.h[0].ID)
+	if root(s).ID != "task2" {
+		t.Errorf("Expected task2 at root after update, got %s", root(s).ID)
+	}
+}
+
+func TestSchedulerWithFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := CreateSchedulerWithClock(clock)
+
+	s.AddOrUpdateAfter("task1", time.Minute)
+	s.AddOrUpdateAfter("task2", 2*time.Minute)
+
+	if due := s.PopDueNow(); len(due) != 0 {
+		t.Errorf("Expected 0 due tasks, got %d", len(due))
+	}
+
+	clock.Advance(time.Minute)
+	due := s.PopDueNow()
+	if len(due) != 1 || due[0].ID != "task1" {
+		t.Errorf("Expected task1 to be due after 1 minute, got %d tasks", len(due))
+	}
+
+	clock.Advance(time.Hour)
+	due = s.PopDueNow()
+	if len(due) != 1 || due[0].ID != "task2" {
+		t.Errorf("Expected task2 to be due after 1 hour, got %d tasks", len(due))
 	}
 }
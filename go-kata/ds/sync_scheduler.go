@@ -0,0 +1,285 @@
+package ds
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyncScheduler is a Scheduler guarded by a mutex, safe to share between
+// goroutines.
+type SyncScheduler struct {
+	mu sync.Mutex
+	s  *Scheduler
+}
+
+func NewSyncScheduler() *SyncScheduler {
+	return NewSyncSchedulerWithClock(RealClock{})
+}
+
+func NewSyncSchedulerWithClock(clock Clock) *SyncScheduler {
+	return &SyncScheduler{s: CreateSchedulerWithClock(clock)}
+}
+
+// NewSyncSchedulerFrom guards a Scheduler that has already been set up, for
+// instance with a retry policy or a limiter. s must not be used directly
+// afterwards.
+func NewSyncSchedulerFrom(s *Scheduler) *SyncScheduler {
+	return &SyncScheduler{s: s}
+}
+
+func (s *SyncScheduler) AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s.AddOrUpdate(ID, deadline, opts...)
+}
+
+func (s *SyncScheduler) AddOrUpdateAfter(ID string, d time.Duration, opts ...TaskOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.s.AddOrUpdateAfter(ID, d, opts...)
+}
+
+func (s *SyncScheduler) AddOrUpdateRecurring(ID string, r Recurring, opts ...TaskOption) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.AddOrUpdateRecurring(ID, r, opts...)
+}
+
+func (s *SyncScheduler) Remove(ID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Remove(ID)
+}
+
+func (s *SyncScheduler) PopDue(deadline time.Time) []*Task {
+	return s.PopDueN(deadline, 0)
+}
+
+func (s *SyncScheduler) PopDueN(deadline time.Time, limit int) []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.PopDueN(deadline, limit)
+}
+
+func (s *SyncScheduler) PopDueNow() []*Task {
+	return s.PopDue(s.s.clock.Now())
+}
+
+func (s *SyncScheduler) PopOne(deadline time.Time) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.PopOne(deadline)
+}
+
+func (s *SyncScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Len()
+}
+
+func (s *SyncScheduler) Get(ID string) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Get(ID)
+}
+
+func (s *SyncScheduler) RunNow(ID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.RunNow(ID)
+}
+
+// Tasks returns copies of the ready tasks in deadline order.
+func (s *SyncScheduler) Tasks() []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]*Task, 0, s.s.ready.Len())
+	for t := range s.s.All() {
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+// Do runs fn with the scheduler locked, so that a change and reading back
+// its result happen in one step. fn must not keep s.
+func (s *SyncScheduler) Do(fn func(s *Scheduler)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.s)
+}
+
+func (s *SyncScheduler) Snapshot() SchedulerSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Snapshot()
+}
+
+func (s *SyncScheduler) Claim(workerID string, now time.Time, d time.Duration) []*Task {
+	return s.ClaimN(workerID, now, d, 0)
+}
+
+func (s *SyncScheduler) ClaimN(workerID string, now time.Time, d time.Duration, limit int) []*Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.ClaimN(workerID, now, d, limit)
+}
+
+func (s *SyncScheduler) RenewLease(ID, workerID string, now time.Time, d time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.RenewLease(ID, workerID, now, d)
+}
+
+func (s *SyncScheduler) Lease(ID string) (workerID string, expires time.Time, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Lease(ID)
+}
+
+func (s *SyncScheduler) ReclaimExpired(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.ReclaimExpired(now)
+}
+
+func (s *SyncScheduler) Ack(ID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Ack(ID)
+}
+
+func (s *SyncScheduler) Nack(ID string, cause error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Nack(ID, cause)
+}
+
+func (s *SyncScheduler) Complete(ID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Complete(ID)
+}
+
+// ShardedScheduler spreads tasks over several independently locked
+// Schedulers by a hash of their ID, so writers to different IDs rarely wait
+// on each other. Pops lock every shard and merge their heads, so tasks still
+// come out in global deadline order.
+type ShardedScheduler struct {
+	shards []*SyncScheduler
+	clock  Clock
+}
+
+func NewShardedScheduler(shards int) *ShardedScheduler {
+	return NewShardedSchedulerWithClock(shards, RealClock{})
+}
+
+func NewShardedSchedulerWithClock(shards int, clock Clock) *ShardedScheduler {
+	s := &ShardedScheduler{
+		shards: make([]*SyncScheduler, max(shards, 1)),
+		clock:  clock,
+	}
+	// One sequence across shards keeps ties in queueing order when the
+	// shards' heads are merged
+	seq := new(atomic.Uint64)
+	for i := range s.shards {
+		s.shards[i] = NewSyncSchedulerWithClock(clock)
+		s.shards[i].s.seq = seq
+	}
+	return s
+}
+
+func (s *ShardedScheduler) shard(ID string) *SyncScheduler {
+	h := fnv.New32a()
+	h.Write([]byte(ID))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *ShardedScheduler) AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption) {
+	s.shard(ID).AddOrUpdate(ID, deadline, opts...)
+}
+
+func (s *ShardedScheduler) AddOrUpdateAfter(ID string, d time.Duration, opts ...TaskOption) {
+	s.shard(ID).AddOrUpdateAfter(ID, d, opts...)
+}
+
+func (s *ShardedScheduler) AddOrUpdateRecurring(ID string, r Recurring, opts ...TaskOption) error {
+	return s.shard(ID).AddOrUpdateRecurring(ID, r, opts...)
+}
+
+func (s *ShardedScheduler) Remove(ID string) bool {
+	return s.shard(ID).Remove(ID)
+}
+
+func (s *ShardedScheduler) PopDue(deadline time.Time) []*Task {
+	return s.PopDueN(deadline, 0)
+}
+
+// PopDueN pops due tasks across all shards in deadline order; limit <= 0
+// means no cap.
+func (s *ShardedScheduler) PopDueN(deadline time.Time, limit int) []*Task {
+
+	// Shards are always locked in the same order, so concurrent pops
+	// cannot deadlock
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+	}
+	defer func() {
+		for _, shard := range s.shards {
+			shard.mu.Unlock()
+		}
+	}()
+
+	var due []*Task = make([]*Task, 0)
+
+	for limit <= 0 || len(due) < limit {
+		var next *Scheduler
+		var head *Task
+		for _, shard := range s.shards {
+			t, ok := shard.s.head()
+			if !ok {
+				continue
+			}
+			if head == nil || t.before(head) {
+				next, head = shard.s, t
+			}
+		}
+
+		if head == nil || head.deadline.After(deadline) {
+			break
+		}
+
+		// Only the chosen head is popped, since the shard's next task may
+		// come after another shard's head. A skipped recurring occurrence
+		// is popped without being returned
+		if t, ok := next.popHead(deadline); ok {
+			due = append(due, t)
+		}
+	}
+
+	return due
+}
+
+func (s *ShardedScheduler) PopDueNow() []*Task {
+	return s.PopDue(s.clock.Now())
+}
+
+func (s *ShardedScheduler) PopOne(deadline time.Time) (*Task, bool) {
+
+	due := s.PopDueN(deadline, 1)
+	if len(due) == 0 {
+		return nil, false
+	}
+
+	return due[0], true
+}
+
+func (s *ShardedScheduler) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
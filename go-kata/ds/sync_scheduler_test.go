@@ -0,0 +1,265 @@
+package ds
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrentScheduler is what the parallel load test drives.
+type concurrentScheduler interface {
+	AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption)
+	Remove(ID string) bool
+	PopDue(deadline time.Time) []*Task
+	Len() int
+}
+
+// hammer runs writers that add, update and remove tasks alongside pollers
+// that pop them, then checks that every surviving task was popped exactly
+// once.
+func hammer(t *testing.T, s concurrentScheduler) {
+	const writers = 8
+	const perWriter = 500
+
+	var (
+		mu     sync.Mutex
+		popped = make(map[string]int)
+		done   = make(chan struct{})
+		wg     sync.WaitGroup
+	)
+
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				id := fmt.Sprintf("w%d-%d", w, i)
+				// Only the update makes the task due for the pollers,
+				// so no ID can be popped and then re-added
+				s.AddOrUpdate(id, epoch.Add(2*time.Hour))
+				s.AddOrUpdate(id, epoch.Add(time.Duration(i)*time.Millisecond), WithPriority(i))
+				if i%10 == 0 {
+					s.Remove(id)
+				}
+			}
+		}()
+	}
+
+	var pollers sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		pollers.Add(1)
+		go func() {
+			defer pollers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, task := range s.PopDue(epoch.Add(time.Hour)) {
+					mu.Lock()
+					popped[task.ID]++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	pollers.Wait()
+
+	for _, task := range s.PopDue(epoch.Add(3 * time.Hour)) {
+		popped[task.ID]++
+	}
+
+	if s.Len() != 0 {
+		t.Errorf("Expected an empty scheduler, got %d tasks", s.Len())
+	}
+
+	for w := 0; w < writers; w++ {
+		for i := 0; i < perWriter; i++ {
+			id := fmt.Sprintf("w%d-%d", w, i)
+			// A removed ID may still have been popped before the Remove
+			// landed, but never twice
+			if popped[id] > 1 {
+				t.Fatalf("Task %s popped %d times", id, popped[id])
+			}
+			if i%10 != 0 && popped[id] != 1 {
+				t.Fatalf("Task %s popped %d times, expected once", id, popped[id])
+			}
+		}
+	}
+}
+
+func TestSyncSchedulerParallel(t *testing.T) {
+	hammer(t, NewSyncScheduler())
+}
+
+func TestShardedSchedulerParallel(t *testing.T) {
+	hammer(t, NewShardedScheduler(8))
+}
+
+func TestShardedSchedulerPopsInDeadlineOrder(t *testing.T) {
+	s := NewShardedScheduler(4)
+
+	for i := 0; i < 100; i++ {
+		// Spread deadlines so neighbouring IDs are far apart in time
+		s.AddOrUpdate(fmt.Sprintf("task-%d", i), epoch.Add(time.Duration((i*37)%100)*time.Second))
+	}
+	s.AddOrUpdate("urgent", epoch.Add(10*time.Second), WithPriority(5))
+
+	due := s.PopDueN(epoch.Add(50*time.Second), 20)
+	if len(due) != 20 {
+		t.Fatalf("Expected 20 tasks, got %d", len(due))
+	}
+
+	if !sort.SliceIsSorted(due, func(i, j int) bool { return due[i].before(due[j]) }) {
+		t.Errorf("Expected tasks in deadline order, got %v", ids(due))
+	}
+	if due[10].ID != "urgent" {
+		t.Errorf("Expected urgent to win its deadline tie, got %s", due[10].ID)
+	}
+
+	rest := s.PopDue(epoch.Add(50 * time.Second))
+	if len(rest) != 32 {
+		t.Errorf("Expected the other 32 due tasks, got %d", len(rest))
+	}
+	if s.Len() != 49 {
+		t.Errorf("Expected 49 tasks left, got %d", s.Len())
+	}
+
+	if _, ok := s.PopOne(epoch.Add(50 * time.Second)); ok {
+		t.Error("Expected nothing left to pop")
+	}
+}
+
+func TestShardedSchedulerRecurring(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewShardedSchedulerWithClock(4, clock)
+
+	s.AddOrUpdateRecurring("tick", Recurring{Schedule: mustEvery(time.Minute)})
+	s.AddOrUpdateAfter("once", 90*time.Second)
+
+	clock.Advance(2 * time.Minute)
+
+	if got := ids(s.PopDueNow()); len(got) != 2 || got[0] != "tick" || got[1] != "once" {
+		t.Errorf("Expected [tick once], got %v", got)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected the next tick to stay queued, got %d tasks", s.Len())
+	}
+}
+
+func TestShardedSchedulerTiesInQueueingOrder(t *testing.T) {
+	s := NewShardedSchedulerWithClock(2, NewFakeClock(epoch))
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("t%d", i)
+		s.AddOrUpdate(id, epoch)
+		want = append(want, id)
+	}
+
+	if got := ids(s.PopDue(epoch)); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// shardedIDs returns an ID on the same shard as ID and one on another.
+func shardedIDs(s *ShardedScheduler, ID string) (same, other string) {
+	for i := 0; same == "" || other == ""; i++ {
+		id := fmt.Sprintf("x%d", i)
+		if s.shard(id) == s.shard(ID) {
+			same = id
+		} else {
+			other = id
+		}
+	}
+	return same, other
+}
+
+func TestShardedSchedulerSkippedOccurrenceKeepsOrder(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := NewShardedSchedulerWithClock(2, clock)
+	same, other := shardedIDs(s, "job")
+
+	s.AddOrUpdateRecurring("job", Recurring{Schedule: mustEvery(time.Minute), CatchUp: CatchUpSkip})
+	s.AddOrUpdate(same, epoch.Add(10*time.Minute))
+	s.AddOrUpdate(other, epoch.Add(5*time.Minute))
+
+	// job's late occurrence is skipped; the task behind it on its shard
+	// must still wait for the other shard's earlier head
+	clock.Advance(20 * time.Minute)
+	if got := ids(s.PopDueNow()); !slices.Equal(got, []string{other, same}) {
+		t.Errorf("Expected [%s %s], got %v", other, same, got)
+	}
+}
+
+// TestSyncSchedulerClaimParallel has workers claim and acknowledge jobs
+// while one of them keeps dying on its claims, and checks that every job is
+// acknowledged exactly once. Run with -race.
+func TestSyncSchedulerClaimParallel(t *testing.T) {
+	const jobs = 2000
+	const workers = 6
+
+	clock := NewFakeClock(epoch)
+	s := NewSyncSchedulerFrom(CreateSchedulerWithClock(clock))
+
+	for i := 0; i < jobs; i++ {
+		s.AddOrUpdate(fmt.Sprintf("job%d", i), epoch)
+	}
+
+	var (
+		mu    sync.Mutex
+		acked = make(map[string]int)
+		wg    sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker := fmt.Sprintf("w%d", w)
+
+			for idle := 0; idle < 100; {
+				claimed := s.ClaimN(worker, clock.Now(), time.Second, 4)
+				if len(claimed) == 0 {
+					idle++
+					clock.Advance(time.Second)
+					continue
+				}
+				idle = 0
+
+				for _, task := range claimed {
+					// Worker 0 crashes on its jobs and never answers
+					if w == 0 {
+						continue
+					}
+					if s.Ack(task.ID) {
+						mu.Lock()
+						acked[task.ID]++
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(acked) != jobs {
+		t.Fatalf("Expected %d jobs acknowledged, got %d", jobs, len(acked))
+	}
+	for id, n := range acked {
+		if n != 1 {
+			t.Fatalf("Job %s acknowledged %d times", id, n)
+		}
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected nothing left queued, got %d", s.Len())
+	}
+}
@@ -0,0 +1,99 @@
+package ds
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+)
+
+// TaskOption sets optional fields on a task as it is added or updated.
+type TaskOption func(*Task)
+
+func WithPayload(payload any) TaskOption {
+	return func(t *Task) { t.Payload = payload }
+}
+
+// WithPriority orders tasks that share a deadline; higher priority pops first.
+func WithPriority(priority int) TaskOption {
+	return func(t *Task) { t.priority = priority }
+}
+
+// WithLabels replaces the task's labels with a copy of labels.
+func WithLabels(labels map[string]string) TaskOption {
+	return func(t *Task) { t.Labels = maps.Clone(labels) }
+}
+
+// WithLabel sets a single label, keeping the others.
+func WithLabel(key, value string) TaskOption {
+	return func(t *Task) {
+		if t.Labels == nil {
+			t.Labels = make(map[string]string)
+		}
+		t.Labels[key] = value
+	}
+}
+
+func (t *Task) Deadline() time.Time { return t.deadline }
+func (t *Task) Priority() int       { return t.priority }
+func (t *Task) Created() time.Time  { return t.created }
+func (t *Task) Updated() time.Time  { return t.updated }
+
+// Attempts is the number of times the task has been popped and failed.
+func (t *Task) Attempts() int { return t.attempts }
+
+// LastError is the error passed to the most recent Nack, if any.
+func (t *Task) LastError() error { return t.lastErr }
+
+// PayloadAs returns the task payload as a T, reporting whether it is one.
+func PayloadAs[T any](t *Task) (T, bool) {
+	v, ok := t.Payload.(T)
+	return v, ok
+}
+
+type taskJSON struct {
+	ID       string            `json:"id"`
+	Deadline time.Time         `json:"deadline"`
+	Priority int               `json:"priority,omitempty"`
+	Payload  any               `json:"payload,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Updated  time.Time         `json:"updated"`
+}
+
+func (t *Task) MarshalJSON() ([]byte, error) {
+	return json.Marshal(taskJSON{
+		ID:       t.ID,
+		Deadline: t.deadline,
+		Priority: t.priority,
+		Payload:  t.Payload,
+		Labels:   t.Labels,
+		Created:  t.created,
+		Updated:  t.updated,
+	})
+}
+
+// UnmarshalJSON restores a task written by MarshalJSON. The payload's type
+// is not recorded, so it comes back as a json.RawMessage.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		taskJSON
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*t = Task{
+		ID:       raw.ID,
+		Labels:   raw.Labels,
+		deadline: raw.Deadline,
+		priority: raw.Priority,
+		created:  raw.Created,
+		updated:  raw.Updated,
+	}
+	if raw.Payload != nil {
+		t.Payload = raw.Payload
+	}
+
+	return nil
+}
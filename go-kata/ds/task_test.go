@@ -0,0 +1,104 @@
+package ds
+
+import (
+	"testing"
+	"time"
+)
+
+type emailJob struct {
+	To string
+}
+
+func TestTaskPayload(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("mail", now, WithPayload(emailJob{To: "ops@example.com"}))
+
+	due := s.PopDue(now)
+	if len(due) != 1 {
+		t.Fatalf("Expected 1 due task, got %d", len(due))
+	}
+
+	job, ok := PayloadAs[emailJob](due[0])
+	if !ok {
+		t.Fatalf("Expected an emailJob payload, got %T", due[0].Payload)
+	}
+	if job.To != "ops@example.com" {
+		t.Errorf("Expected payload to survive, got %+v", job)
+	}
+
+	if _, ok := PayloadAs[string](due[0]); ok {
+		t.Error("Expected PayloadAs with the wrong type to fail")
+	}
+}
+
+func TestTaskPriorityBreaksTies(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("low", now, WithPriority(1))
+	s.AddOrUpdate("high", now, WithPriority(10))
+	s.AddOrUpdate("earlier", now.Add(-time.Second))
+	s.AddOrUpdate("medium", now, WithPriority(5))
+
+	due := s.PopDue(now)
+
+	expected := []string{"earlier", "high", "medium", "low"}
+	for i, id := range expected {
+		if due[i].ID != id {
+			t.Errorf("Expected %s at position %d, got %s", id, i, due[i].ID)
+		}
+	}
+}
+
+func TestTaskPriorityUpdateReordersHeap(t *testing.T) {
+	s := CreateScheduler()
+	now := time.Now()
+
+	s.AddOrUpdate("a", now, WithPriority(2))
+	s.AddOrUpdate("b", now, WithPriority(1))
+
+	if root(s).ID != "a" {
+		t.Fatalf("Expected a at root, got %s", root(s).ID)
+	}
+
+	s.AddOrUpdate("b", now, WithPriority(3))
+	if root(s).ID != "b" {
+		t.Errorf("Expected b at root after raising its priority, got %s", root(s).ID)
+	}
+}
+
+func TestTaskMetadata(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	s := CreateSchedulerWithClock(clock)
+
+	labels := map[string]string{"team": "billing"}
+	s.AddOrUpdate("job", epoch.Add(time.Hour), WithLabels(labels), WithPayload(42), WithPriority(7))
+
+	// The task keeps its own copy of the labels
+	labels["team"] = "changed"
+
+	clock.Advance(time.Minute)
+	s.AddOrUpdate("job", epoch.Add(2*time.Hour), WithLabel("env", "prod"))
+
+	task := s.byID["job"]
+
+	if !task.Deadline().Equal(epoch.Add(2 * time.Hour)) {
+		t.Errorf("Expected deadline %v, got %v", epoch.Add(2*time.Hour), task.Deadline())
+	}
+	if !task.Created().Equal(epoch) {
+		t.Errorf("Expected created %v, got %v", epoch, task.Created())
+	}
+	if !task.Updated().Equal(epoch.Add(time.Minute)) {
+		t.Errorf("Expected updated %v, got %v", epoch.Add(time.Minute), task.Updated())
+	}
+
+	// Fields not mentioned in the update are kept
+	if task.Priority() != 7 || task.Payload != 42 {
+		t.Errorf("Expected priority 7 and payload 42 to be kept, got %d and %v", task.Priority(), task.Payload)
+	}
+	if task.Labels["team"] != "billing" || task.Labels["env"] != "prod" {
+		t.Errorf("Expected labels team=billing env=prod, got %v", task.Labels)
+	}
+}
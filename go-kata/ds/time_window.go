@@ -0,0 +1,158 @@
+package ds
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var ErrLateSample = errors.New("Sample arrived after its window was emitted!!")
+
+type Sample struct {
+	At  time.Time
+	Val int
+}
+
+// TimedMinMax is the min/max over the window ending at (and including) At.
+type TimedMinMax struct {
+	At  time.Time
+	Min int
+	Max int
+}
+
+// TimeWindow computes rolling min/max over a duration rather than a count:
+// each sample is reported with the extremes of every sample in
+// (At-width, At]. Samples may arrive up to lateness out of order; they are
+// held in a reorder buffer until the newest timestamp seen has moved
+// lateness past them, so the results are emitted in timestamp order.
+type TimeWindow struct {
+	width    time.Duration
+	lateness time.Duration
+
+	pending   []Sample  // reorder buffer, sorted by At
+	watermark time.Time // newest At seen
+	released  time.Time // At of the last sample applied to the window
+	started   bool
+
+	samples []Sample // applied samples still in the window; samples[i] has seq base+i
+	base    int
+
+	maxQ *Deque // Monotonic ordering (seqs)
+	minQ *Deque
+}
+
+func NewTimeWindow(width, lateness time.Duration) (*TimeWindow, error) {
+	if width <= 0 {
+		return nil, errors.New("Window width must be positive!!")
+	}
+	if lateness < 0 {
+		return nil, errors.New("Lateness must not be negative!!")
+	}
+
+	return &TimeWindow{
+		width:    width,
+		lateness: lateness,
+		maxQ:     NewDeque(16),
+		minQ:     NewDeque(16),
+	}, nil
+}
+
+func (w *TimeWindow) sample(seq int) Sample { return w.samples[seq-w.base] }
+
+// Push adds a sample and returns the results for every sample that is now
+// older than the lateness tolerance. A sample earlier than one already
+// emitted is rejected with ErrLateSample.
+func (w *TimeWindow) Push(s Sample) ([]TimedMinMax, error) {
+
+	if w.started && s.At.Before(w.released) {
+		return nil, ErrLateSample
+	}
+
+	// Insert after any samples with the same timestamp to keep arrival order
+	pos := sort.Search(len(w.pending), func(i int) bool { return w.pending[i].At.After(s.At) })
+	w.pending = append(w.pending, Sample{})
+	copy(w.pending[pos+1:], w.pending[pos:])
+	w.pending[pos] = s
+
+	if s.At.After(w.watermark) {
+		w.watermark = s.At
+	}
+
+	return w.release(w.watermark.Add(-w.lateness)), nil
+}
+
+// Flush applies everything still in the reorder buffer, as if no more late
+// samples could arrive.
+func (w *TimeWindow) Flush() []TimedMinMax {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	return w.release(w.pending[len(w.pending)-1].At)
+}
+
+func (w *TimeWindow) release(upTo time.Time) []TimedMinMax {
+	var out []TimedMinMax
+
+	for len(w.pending) > 0 && !w.pending[0].At.After(upTo) {
+		s := w.pending[0]
+		w.pending = w.pending[1:]
+		out = append(out, w.apply(s))
+	}
+
+	return out
+}
+
+func (w *TimeWindow) apply(s Sample) TimedMinMax {
+
+	w.started = true
+	w.released = s.At
+
+	// Drop any samples that fell out of (s.At-width, s.At]
+	cutoff := s.At.Add(-w.width)
+
+	w.purge(w.maxQ, cutoff)
+	w.purge(w.minQ, cutoff)
+
+	for len(w.samples) > 0 && !w.samples[0].At.After(cutoff) {
+		w.samples = w.samples[1:]
+		w.base++
+	}
+
+	seq := w.base + len(w.samples)
+	w.samples = append(w.samples, s)
+
+	w.monotonicPush(w.maxQ, seq, func(old, new int) bool { return old > new })
+	w.monotonicPush(w.minQ, seq, func(old, new int) bool { return old < new })
+
+	max_seq, _ := w.maxQ.PeekFront()
+	min_seq, _ := w.minQ.PeekFront()
+
+	return TimedMinMax{At: s.At, Min: w.sample(min_seq).Val, Max: w.sample(max_seq).Val}
+}
+
+func (w *TimeWindow) purge(Q *Deque, cutoff time.Time) {
+	for !Q.Empty() {
+		front_seq, _ := Q.PeekFront()
+		if w.sample(front_seq).At.After(cutoff) {
+			break
+		}
+		Q.PopFront()
+	}
+}
+
+func (w *TimeWindow) monotonicPush(Q *Deque, seq int, keep func(old, new int) bool) {
+	new_value := w.sample(seq).Val
+
+	for !Q.Empty() {
+		back_seq, _ := Q.PeekBack()
+		if keep(w.sample(back_seq).Val, new_value) {
+			break
+		}
+		Q.PopBack()
+	}
+
+	if Q.Full() {
+		Q.grow()
+	}
+	Q.PushBack(seq)
+}
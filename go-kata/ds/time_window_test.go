@@ -0,0 +1,156 @@
+package ds
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimeWindow_InOrder(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	w, err := NewTimeWindow(10*time.Second, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	samples := []Sample{
+		{At: at(0), Val: 5},
+		{At: at(3), Val: 9},
+		{At: at(4), Val: 1},
+		{At: at(12), Val: 4}, // at(0) has expired
+		{At: at(15), Val: 7}, // at(3) and at(4) have expired
+		{At: at(40), Val: 3}, // everything else has expired
+	}
+
+	var got []TimedMinMax
+	for _, s := range samples {
+		out, err := w.Push(s)
+		if err != nil {
+			t.Fatalf("Push(%v) returned error: %v", s, err)
+		}
+		got = append(got, out...)
+	}
+
+	expected := []TimedMinMax{
+		{At: at(0), Min: 5, Max: 5},
+		{At: at(3), Min: 5, Max: 9},
+		{At: at(4), Min: 1, Max: 9},
+		{At: at(12), Min: 1, Max: 9},
+		{At: at(15), Min: 4, Max: 7},
+		{At: at(40), Min: 3, Max: 3},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestTimeWindow_WindowBoundaryIsExclusive(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w, _ := NewTimeWindow(time.Second, 0)
+	w.Push(Sample{At: base, Val: 100})
+
+	out, _ := w.Push(Sample{At: base.Add(time.Second), Val: 1})
+	if len(out) != 1 || out[0].Max != 1 {
+		t.Errorf("Sample exactly width old should have expired, got %v", out)
+	}
+}
+
+func TestTimeWindow_OutOfOrder(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	w, _ := NewTimeWindow(5*time.Second, 2*time.Second)
+
+	var got []TimedMinMax
+	push := func(sec, val int) {
+		out, err := w.Push(Sample{At: at(sec), Val: val})
+		if err != nil {
+			t.Fatalf("Push at %ds returned error: %v", sec, err)
+		}
+		got = append(got, out...)
+	}
+
+	push(0, 3)
+	push(2, 8) // releases at(0)
+	push(1, 6) // late but within tolerance
+	push(6, 2) // releases at(1), at(2)
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 results before flush, got %v", got)
+	}
+
+	got = append(got, w.Flush()...)
+
+	expected := []TimedMinMax{
+		{At: at(0), Min: 3, Max: 3},
+		{At: at(1), Min: 3, Max: 6},
+		{At: at(2), Min: 3, Max: 8},
+		{At: at(6), Min: 2, Max: 8},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if _, err := w.Push(Sample{At: at(3), Val: 0}); err != ErrLateSample {
+		t.Errorf("Expected ErrLateSample, got %v", err)
+	}
+}
+
+func TestTimeWindow_ManySamples(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Enough samples per window to force the deques to grow
+	nums := make([]int, 200)
+	for i := range nums {
+		nums[i] = (i * 37) % 101
+	}
+
+	w, _ := NewTimeWindow(50*time.Millisecond, 0)
+
+	var mins, maxs []int
+	for i, v := range nums {
+		out, _ := w.Push(Sample{At: base.Add(time.Duration(i) * time.Millisecond), Val: v})
+		for _, r := range out {
+			mins = append(mins, r.Min)
+			maxs = append(maxs, r.Max)
+		}
+	}
+
+	expectedMins, expectedMaxs, _ := RollingMinMax(nums, 50)
+	if !reflect.DeepEqual(mins[49:], expectedMins) {
+		t.Errorf("mins = %v, want %v", mins[49:], expectedMins)
+	}
+	if !reflect.DeepEqual(maxs[49:], expectedMaxs) {
+		t.Errorf("maxs = %v, want %v", maxs[49:], expectedMaxs)
+	}
+}
+
+func TestTimeWindow_InvalidArgs(t *testing.T) {
+	if _, err := NewTimeWindow(0, 0); err == nil {
+		t.Error("Expected error for zero width")
+	}
+	if _, err := NewTimeWindow(time.Second, -time.Second); err == nil {
+		t.Error("Expected error for negative lateness")
+	}
+}
+
+func TestDequeGrow(t *testing.T) {
+	d := NewDeque(2)
+	d.PushBack(1)
+	d.PushFront(0)
+	d.grow()
+	d.PushBack(2)
+
+	if d.Len() != 3 || d.capacity != 4 {
+		t.Errorf("Expected length 3 capacity 4, got length %d capacity %d", d.Len(), d.capacity)
+	}
+
+	for want := 0; want < 3; want++ {
+		if val, _ := d.PopFront(); val != want {
+			t.Errorf("Expected %d, got %d", want, val)
+		}
+	}
+}
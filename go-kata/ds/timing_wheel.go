@@ -0,0 +1,368 @@
+package ds
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 6
+)
+
+// wheelEntry is a task linked into a wheel slot. The list is intrusive so
+// that scheduling a task costs a single allocation.
+type wheelEntry struct {
+	Task
+	tick uint64
+
+	level int // -1 for the overflow list
+	slot  int
+
+	prev *wheelEntry
+	next *wheelEntry
+}
+
+// TimingWheel is a TaskQueue built on a hierarchical timing wheel. Time is
+// cut into ticks of a fixed resolution; level 0 has one slot per tick, and
+// each higher level has slots 64 times as wide. A task goes into the lowest
+// level whose current rotation covers its tick, and is cascaded down as the
+// wheel turns. Tasks further out than the top level can reach wait in an
+// overflow list.
+//
+// Insert and cancel are O(1). Popping costs O(levels) per occupied slot the
+// wheel passes, plus sorting the due entries of each level 0 slot it drains;
+// per-level occupancy bitmaps let it skip empty stretches without visiting
+// every tick. A capped pop stops turning the wheel once it has enough.
+//
+// A task becomes poppable once the wheel reaches its tick, but PopDue still
+// holds back any task whose exact deadline is after the one asked for.
+type TimingWheel struct {
+	start time.Time
+	tick  time.Duration
+	clock Clock
+
+	now      uint64 // current tick
+	slots    [wheelLevels][wheelSlots]*wheelEntry
+	occupied [wheelLevels]uint64
+	overflow *wheelEntry
+
+	byID map[string]*wheelEntry
+	seq  uint64
+
+	sorted  bool          // the current level 0 slot is in deadline order
+	scratch []*wheelEntry // reused by sortSlot
+}
+
+func NewTimingWheel(tick time.Duration) (*TimingWheel, error) {
+	return NewTimingWheelWithClock(tick, RealClock{})
+}
+
+// NewTimingWheelWithClock starts the wheel at the clock's current time. With
+// a tick of one millisecond the levels reach about two years ahead.
+func NewTimingWheelWithClock(tick time.Duration, clock Clock) (*TimingWheel, error) {
+
+	if tick <= 0 {
+		return nil, errors.New("Tick must be positive!!")
+	}
+
+	return &TimingWheel{
+		start: clock.Now(),
+		tick:  tick,
+		clock: clock,
+		byID:  make(map[string]*wheelEntry),
+	}, nil
+}
+
+// tickOf returns the tick a deadline falls in; deadlines before the start of
+// the wheel fall in tick 0.
+func (w *TimingWheel) tickOf(deadline time.Time) uint64 {
+	d := deadline.Sub(w.start)
+	if d <= 0 {
+		return 0
+	}
+	return uint64(d / w.tick)
+}
+
+// place files e into the slot for its tick relative to the current tick.
+// Ticks already passed go into the current level 0 slot.
+func (w *TimingWheel) place(e *wheelEntry) {
+
+	level, slot := 0, int(w.now&wheelMask)
+
+	if e.tick > w.now {
+		level = -1
+		for l := 0; l < wheelLevels; l++ {
+			shift := uint(wheelBits * (l + 1))
+			if e.tick>>shift == w.now>>shift {
+				level, slot = l, int(e.tick>>(wheelBits*l))&wheelMask
+				break
+			}
+		}
+	}
+
+	e.level, e.slot = level, slot
+	if level == 0 && slot == int(w.now&wheelMask) {
+		w.sorted = false
+	}
+
+	head := w.head(e)
+	e.prev, e.next = nil, *head
+	if *head != nil {
+		(*head).prev = e
+	}
+	*head = e
+
+	if level >= 0 {
+		w.occupied[level] |= 1 << uint(slot)
+	}
+}
+
+// head returns the list e is filed in.
+func (w *TimingWheel) head(e *wheelEntry) **wheelEntry {
+	if e.level < 0 {
+		return &w.overflow
+	}
+	return &w.slots[e.level][e.slot]
+}
+
+// unlink takes e out of its slot.
+func (w *TimingWheel) unlink(e *wheelEntry) {
+
+	head := w.head(e)
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		*head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	}
+	e.prev, e.next = nil, nil
+
+	if e.level >= 0 && *head == nil {
+		w.occupied[e.level] &^= 1 << uint(e.slot)
+	}
+}
+
+func (w *TimingWheel) AddOrUpdateAfter(ID string, d time.Duration, opts ...TaskOption) {
+	w.AddOrUpdate(ID, w.clock.Now().Add(d), opts...)
+}
+
+func (w *TimingWheel) AddOrUpdate(ID string, deadline time.Time, opts ...TaskOption) {
+
+	now := w.clock.Now()
+
+	e, ok := w.byID[ID]
+	if ok {
+		w.unlink(e)
+	} else {
+		w.seq++
+		e = &wheelEntry{Task: Task{ID: ID, created: now, seq: w.seq}}
+		w.byID[ID] = e
+	}
+
+	e.deadline = deadline
+	e.updated = now
+	for _, opt := range opts {
+		opt(&e.Task)
+	}
+
+	e.tick = w.tickOf(deadline)
+	w.place(e)
+}
+
+func (w *TimingWheel) Remove(ID string) bool {
+
+	e, ok := w.byID[ID]
+	if !ok {
+		return false
+	}
+
+	w.unlink(e)
+	delete(w.byID, ID)
+
+	return true
+}
+
+func (w *TimingWheel) Len() int {
+	return len(w.byID)
+}
+
+// nextEvent returns the earliest tick after the current one at which an
+// occupied slot starts, or false if every level is empty past the current
+// tick.
+func (w *TimingWheel) nextEvent() (uint64, bool) {
+
+	var best uint64
+	found := false
+
+	for l := 0; l < wheelLevels; l++ {
+		shift := uint(wheelBits * l)
+		idx := (w.now >> shift) & wheelMask
+
+		// Slots strictly after the current one in this rotation; for
+		// the last slot the mask wraps to all ones
+		later := w.occupied[l] &^ (uint64(2)<<idx - 1)
+		if later == 0 {
+			continue
+		}
+
+		slot := uint64(bits.TrailingZeros64(later))
+		t := w.now>>(shift+wheelBits)<<(shift+wheelBits) | slot<<shift
+
+		if !found || t < best {
+			best, found = t, true
+		}
+	}
+
+	if w.overflow != nil {
+		top := uint(wheelBits * wheelLevels)
+		t := (w.now>>top + 1) << top
+		if !found || t < best {
+			best, found = t, true
+		}
+	}
+
+	return best, found
+}
+
+// moveTo advances the current tick and cascades every slot that now covers
+// it down to lower levels.
+func (w *TimingWheel) moveTo(tick uint64) {
+
+	top := uint(wheelBits * wheelLevels)
+	wrapped := tick>>top != w.now>>top
+	w.now = tick
+	w.sorted = false
+
+	if wrapped {
+		w.cascade(&w.overflow)
+	}
+
+	for l := wheelLevels - 1; l > 0; l-- {
+		slot := int(w.now>>(wheelBits*l)) & wheelMask
+		if w.occupied[l]&(1<<uint(slot)) == 0 {
+			continue
+		}
+		w.occupied[l] &^= 1 << uint(slot)
+		w.cascade(&w.slots[l][slot])
+	}
+}
+
+// cascade re-files every entry in the list at head relative to the current
+// tick.
+func (w *TimingWheel) cascade(head **wheelEntry) {
+	e := *head
+	*head = nil
+
+	for e != nil {
+		next := e.next
+		w.place(e)
+		e = next
+	}
+}
+
+// sortSlot relinks the list at head in deadline order.
+func (w *TimingWheel) sortSlot(head **wheelEntry) {
+
+	entries := w.scratch[:0]
+	for e := *head; e != nil; e = e.next {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].before(&entries[j].Task) })
+
+	var prev *wheelEntry
+	for _, e := range entries {
+		e.prev = prev
+		if prev != nil {
+			prev.next = e
+		} else {
+			*head = e
+		}
+		prev = e
+	}
+	if prev != nil {
+		prev.next = nil
+	}
+
+	clear(entries)
+	w.scratch = entries[:0]
+}
+
+// drain appends to due, in order, the entries in the current level 0 slot
+// whose deadline is at or before deadline, until due holds limit tasks. The
+// slot is sorted once and stays sorted until something is filed into it, so
+// repeated capped pops from a crowded slot only take from its head.
+func (w *TimingWheel) drain(deadline time.Time, due []*Task, limit int) []*Task {
+
+	head := &w.slots[0][w.now&wheelMask]
+	if !w.sorted {
+		w.sortSlot(head)
+		w.sorted = true
+	}
+
+	for e := *head; e != nil && !e.deadline.After(deadline); e = *head {
+		if limit > 0 && len(due) >= limit {
+			break
+		}
+		w.unlink(e)
+		delete(w.byID, e.ID)
+		due = append(due, &e.Task)
+	}
+
+	return due
+}
+
+func (w *TimingWheel) PopDue(deadline time.Time) []*Task {
+	return w.PopDueN(deadline, 0)
+}
+
+// PopDueN turns the wheel up to deadline and pops what is due, in deadline
+// order; limit <= 0 means no cap. Every entry in the current slot is due
+// before any in a later one, so a capped pop stops turning the wheel as soon
+// as it has limit tasks, and the rest stay where they are filed.
+func (w *TimingWheel) PopDueN(deadline time.Time, limit int) []*Task {
+
+	target := w.tickOf(deadline)
+
+	var due []*Task = make([]*Task, 0)
+
+	for {
+		due = w.drain(deadline, due, limit)
+
+		if w.now >= target || (limit > 0 && len(due) >= limit) {
+			break
+		}
+
+		next, ok := w.nextEvent()
+		if !ok || next > target {
+			// Nothing is filed between here and the target, so no
+			// slot needs cascading on the way
+			w.moveTo(target)
+			due = w.drain(deadline, due, limit)
+			break
+		}
+		w.moveTo(next)
+	}
+
+	return due
+}
+
+func (w *TimingWheel) PopDueNow() []*Task {
+	return w.PopDue(w.clock.Now())
+}
+
+func (w *TimingWheel) PopOne(deadline time.Time) (*Task, bool) {
+
+	due := w.PopDueN(deadline, 1)
+	if len(due) == 0 {
+		return nil, false
+	}
+
+	return due[0], true
+}
@@ -0,0 +1,272 @@
+package ds
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func newTestWheel(t testing.TB, tick time.Duration) (*TimingWheel, *FakeClock) {
+	t.Helper()
+
+	clock := NewFakeClock(epoch)
+	w, err := NewTimingWheelWithClock(tick, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w, clock
+}
+
+func TestTimingWheelRejectsBadTick(t *testing.T) {
+	if _, err := NewTimingWheel(0); err == nil {
+		t.Error("Expected an error for a zero tick")
+	}
+}
+
+func TestTimingWheelPopDue(t *testing.T) {
+	w, _ := newTestWheel(t, time.Millisecond)
+
+	w.AddOrUpdate("c", epoch.Add(3*time.Second))
+	w.AddOrUpdate("a", epoch.Add(time.Second))
+	w.AddOrUpdate("b", epoch.Add(2*time.Second))
+	w.AddOrUpdate("far", epoch.Add(400*24*time.Hour))
+
+	if due := w.PopDue(epoch.Add(500 * time.Millisecond)); len(due) != 0 {
+		t.Fatalf("Expected nothing due, got %v", ids(due))
+	}
+
+	due := w.PopDue(epoch.Add(2 * time.Second))
+	if got := ids(due); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("Expected [a b], got %v", got)
+	}
+
+	if w.Len() != 2 {
+		t.Errorf("Expected 2 tasks left, got %d", w.Len())
+	}
+
+	due = w.PopDue(epoch.Add(500 * 24 * time.Hour))
+	if got := ids(due); len(got) != 2 || got[0] != "c" || got[1] != "far" {
+		t.Errorf("Expected [c far], got %v", got)
+	}
+}
+
+func TestTimingWheelHoldsBackWithinTick(t *testing.T) {
+	w, _ := newTestWheel(t, time.Second)
+
+	w.AddOrUpdate("early", epoch.Add(5*time.Second+200*time.Millisecond))
+	w.AddOrUpdate("late", epoch.Add(5*time.Second+800*time.Millisecond))
+
+	due := w.PopDue(epoch.Add(5*time.Second + 500*time.Millisecond))
+	if got := ids(due); len(got) != 1 || got[0] != "early" {
+		t.Fatalf("Expected [early], got %v", got)
+	}
+
+	due = w.PopDue(epoch.Add(5*time.Second + 900*time.Millisecond))
+	if got := ids(due); len(got) != 1 || got[0] != "late" {
+		t.Errorf("Expected [late], got %v", got)
+	}
+}
+
+func TestTimingWheelUpdateAndRemove(t *testing.T) {
+	w, clock := newTestWheel(t, time.Millisecond)
+
+	w.AddOrUpdate("a", epoch.Add(time.Hour))
+	w.AddOrUpdate("b", epoch.Add(time.Minute))
+	w.AddOrUpdate("a", epoch.Add(time.Second), WithPriority(2))
+	w.AddOrUpdateAfter("c", time.Second, WithPriority(1))
+
+	if !w.Remove("b") {
+		t.Error("Expected b to be removed")
+	}
+	if w.Remove("b") {
+		t.Error("Expected a second Remove to fail")
+	}
+
+	clock.Advance(time.Hour)
+
+	due := w.PopDueNow()
+	if got := ids(due); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("Expected [a c], got %v", got)
+	}
+	if due[0].Priority() != 2 {
+		t.Errorf("Expected a to keep priority 2, got %d", due[0].Priority())
+	}
+}
+
+func TestTimingWheelPopDueNLimit(t *testing.T) {
+	w, _ := newTestWheel(t, time.Millisecond)
+
+	for i := 0; i < 10; i++ {
+		w.AddOrUpdate(fmt.Sprintf("t%d", i), epoch.Add(time.Duration(10-i)*time.Second))
+	}
+
+	first := w.PopDueN(epoch.Add(time.Minute), 3)
+	if got := ids(first); len(got) != 3 || got[0] != "t9" || got[2] != "t7" {
+		t.Fatalf("Expected [t9 t8 t7], got %v", got)
+	}
+
+	one, ok := w.PopOne(epoch.Add(time.Minute))
+	if !ok || one.ID != "t6" {
+		t.Errorf("Expected t6, got %v", one)
+	}
+
+	if rest := w.PopDue(epoch.Add(time.Minute)); len(rest) != 6 {
+		t.Errorf("Expected the remaining 6 tasks, got %d", len(rest))
+	}
+}
+
+// TestTimingWheelMatchesScheduler drives the wheel and the heap Scheduler
+// with the same random operations and expects the same output.
+func TestTimingWheelMatchesScheduler(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, tick := range []time.Duration{time.Millisecond, 7 * time.Millisecond, time.Second} {
+		w, _ := newTestWheel(t, tick)
+		s := CreateSchedulerWithClock(NewFakeClock(epoch))
+
+		now := epoch
+		for step := 0; step < 20000; step++ {
+			id := fmt.Sprintf("t%d", rng.IntN(500))
+
+			switch op := rng.IntN(10); {
+			case op < 6:
+				// Mostly near deadlines, a few far into the future or
+				// already in the past
+				offset := time.Duration(rng.Int64N(int64(10 * time.Second)))
+				switch rng.IntN(20) {
+				case 0:
+					offset = time.Duration(rng.Int64N(int64(1000 * 24 * time.Hour)))
+				case 1:
+					offset = -offset
+				}
+				priority := rng.IntN(3)
+				w.AddOrUpdate(id, now.Add(offset), WithPriority(priority))
+				s.AddOrUpdate(id, now.Add(offset), WithPriority(priority))
+			case op < 8:
+				if w.Remove(id) != s.Remove(id) {
+					t.Fatalf("Tick %v step %d: Remove(%s) disagrees", tick, step, id)
+				}
+			default:
+				now = now.Add(time.Duration(rng.Int64N(int64(3 * time.Second))))
+				if rng.IntN(200) == 0 {
+					now = now.Add(500 * 24 * time.Hour)
+				}
+				limit := rng.IntN(4)
+
+				got, want := w.PopDueN(now, limit), s.PopDueN(now, limit)
+				if len(got) != len(want) {
+					t.Fatalf("Tick %v step %d: expected %v, got %v", tick, step, ids(want), ids(got))
+				}
+				// Deadlines are random nanoseconds, so there are no
+				// ties and the order is fully determined
+				for i := range want {
+					if got[i].ID != want[i].ID {
+						t.Fatalf("Tick %v step %d: expected %v, got %v", tick, step, ids(want), ids(got))
+					}
+				}
+			}
+
+			if w.Len() != s.Len() {
+				t.Fatalf("Tick %v step %d: expected %d tasks, got %d", tick, step, s.Len(), w.Len())
+			}
+		}
+	}
+}
+
+const benchTasks = 100_000
+
+func benchmarkAddRemove(b *testing.B, q TaskQueue) {
+	for i := 0; i < benchTasks; i++ {
+		q.AddOrUpdate(fmt.Sprintf("bg%d", i), epoch.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	names := make([]string, 1024)
+	for i := range names {
+		names[i] = fmt.Sprintf("t%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := names[i%len(names)]
+		q.AddOrUpdate(id, epoch.Add(time.Duration(i%benchTasks)*time.Millisecond))
+		q.Remove(id)
+	}
+}
+
+// benchmarkAddPop fills a fresh queue and drains it in 100ms steps.
+func benchmarkAddPop(b *testing.B, newQueue func() TaskQueue) {
+	names := make([]string, benchTasks)
+	for i := range names {
+		names[i] = fmt.Sprintf("t%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		q := newQueue()
+		b.StartTimer()
+
+		for j, id := range names {
+			q.AddOrUpdate(id, epoch.Add(time.Duration(j*7919%benchTasks)*time.Millisecond))
+		}
+		for step := time.Duration(0); step <= benchTasks; step += 100 {
+			q.PopDue(epoch.Add(step * time.Millisecond))
+		}
+	}
+}
+
+func BenchmarkSchedulerAddRemove(b *testing.B) {
+	benchmarkAddRemove(b, CreateSchedulerWithClock(NewFakeClock(epoch)))
+}
+
+func BenchmarkTimingWheelAddRemove(b *testing.B) {
+	w, _ := newTestWheel(b, time.Millisecond)
+	benchmarkAddRemove(b, w)
+}
+
+func BenchmarkSchedulerAddPop(b *testing.B) {
+	benchmarkAddPop(b, func() TaskQueue { return CreateSchedulerWithClock(NewFakeClock(epoch)) })
+}
+
+func BenchmarkTimingWheelAddPop(b *testing.B) {
+	benchmarkAddPop(b, func() TaskQueue {
+		w, _ := newTestWheel(b, time.Millisecond)
+		return w
+	})
+}
+
+func BenchmarkSchedulerPopOne(b *testing.B) {
+	benchmarkPopOne(b, func() TaskQueue { return CreateSchedulerWithClock(NewFakeClock(epoch)) })
+}
+
+func BenchmarkTimingWheelPopOne(b *testing.B) {
+	benchmarkPopOne(b, func() TaskQueue {
+		w, _ := newTestWheel(b, time.Millisecond)
+		return w
+	})
+}
+
+// benchmarkPopOne pops a large overdue backlog one task at a time, the way
+// a worker pulling single jobs would.
+func benchmarkPopOne(b *testing.B, newQueue func() TaskQueue) {
+	names := make([]string, benchTasks)
+	for i := range names {
+		names[i] = fmt.Sprintf("t%d", i)
+	}
+	end := epoch.Add(benchTasks * time.Millisecond)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		q := newQueue()
+		for j, id := range names {
+			// Ten tasks share each tick
+			q.AddOrUpdate(id, epoch.Add(time.Duration(j*7919%benchTasks/10)*time.Millisecond))
+		}
+		b.StartTimer()
+
+		for len(q.PopDueN(end, 1)) == 1 {
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package ds
+
+import (
+	"log/slog"
+
+	"go-kata/internal/tracing"
+)
+
+// SetTracer routes algorithm traces to l. Pass nil to turn tracing off. The
+// tracer is shared with package kata.
+func SetTracer(l *slog.Logger) {
+	tracing.Set(l)
+}
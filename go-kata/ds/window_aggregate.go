@@ -0,0 +1,143 @@
+package ds
+
+import (
+	"errors"
+	"math"
+)
+
+// Monoid is an associative Combine with an Identity element. Combine does
+// not need to be commutative: the aggregate of a window is always folded
+// oldest to newest.
+type Monoid[T any] struct {
+	Identity T
+	Combine  func(a, b T) T
+}
+
+func SumMonoid() Monoid[int] {
+	return Monoid[int]{Identity: 0, Combine: func(a, b int) int { return a + b }}
+}
+
+func MinMonoid() Monoid[int] {
+	return Monoid[int]{Identity: math.MaxInt, Combine: func(a, b int) int { return min(a, b) }}
+}
+
+func MaxMonoid() Monoid[int] {
+	return Monoid[int]{Identity: math.MinInt, Combine: func(a, b int) int { return max(a, b) }}
+}
+
+func GCDMonoid() Monoid[int] {
+	return Monoid[int]{Identity: 0, Combine: gcd}
+}
+
+func gcd(a, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+type aggEntry[T any] struct {
+	val T
+	agg T
+}
+
+// WindowAggregator keeps the aggregate of the last k values using the
+// two-stack queue: pushes land on back, evictions come off front, and front
+// is refilled from back (reversed) only when it runs dry, so every value is
+// moved once and Push/Query are O(1) amortized.
+//
+// front[i].agg folds front[i..0] (oldest first) and back[i].agg folds
+// back[0..i], so the window aggregate is front.top.agg ⊕ back.top.agg.
+type WindowAggregator[T any] struct {
+	k     int
+	m     Monoid[T]
+	front []aggEntry[T]
+	back  []aggEntry[T]
+}
+
+func NewWindowAggregator[T any](k int, m Monoid[T]) (*WindowAggregator[T], error) {
+	if k <= 0 {
+		return nil, errors.New("Window size must be positive!!")
+	}
+
+	return &WindowAggregator[T]{
+		k:     k,
+		m:     m,
+		front: make([]aggEntry[T], 0, k),
+		back:  make([]aggEntry[T], 0, k),
+	}, nil
+}
+
+func (w *WindowAggregator[T]) Len() int   { return len(w.front) + len(w.back) }
+func (w *WindowAggregator[T]) Full() bool { return w.Len() == w.k }
+
+func (w *WindowAggregator[T]) top(stack []aggEntry[T]) T {
+	if len(stack) == 0 {
+		return w.m.Identity
+	}
+	return stack[len(stack)-1].agg
+}
+
+// Push appends v to the window, evicting the oldest value if the window is
+// already full.
+func (w *WindowAggregator[T]) Push(v T) {
+	if w.Full() {
+		w.evict()
+	}
+
+	w.back = append(w.back, aggEntry[T]{val: v, agg: w.m.Combine(w.top(w.back), v)})
+}
+
+func (w *WindowAggregator[T]) evict() {
+	if len(w.front) == 0 {
+		for len(w.back) > 0 {
+			e := w.back[len(w.back)-1]
+			w.back = w.back[:len(w.back)-1]
+			w.front = append(w.front, aggEntry[T]{val: e.val, agg: w.m.Combine(e.val, w.top(w.front))})
+		}
+	}
+
+	w.front = w.front[:len(w.front)-1]
+}
+
+// Query returns the aggregate of the values currently in the window.
+func (w *WindowAggregator[T]) Query() T {
+	return w.m.Combine(w.top(w.front), w.top(w.back))
+}
+
+// RollingAggregate applies m over every window of k values in nums, with the
+// same window semantics as RollingMinMax: k larger than len(nums) yields a
+// single window over the whole slice.
+func RollingAggregate[T any](nums []T, k int, m Monoid[T]) ([]T, error) {
+
+	if k <= 0 {
+		return nil, errors.New("Window size must be positive!!")
+	}
+
+	out := make([]T, 0, max(len(nums)-k+1, 1))
+	if len(nums) == 0 {
+		return out, nil
+	}
+
+	k = min(len(nums), k)
+
+	w, err := NewWindowAggregator(k, m)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range nums {
+		w.Push(v)
+		if w.Full() {
+			out = append(out, w.Query())
+		}
+	}
+
+	return out, nil
+}
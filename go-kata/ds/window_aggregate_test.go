@@ -0,0 +1,154 @@
+package ds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRollingAggregate_Monoids(t *testing.T) {
+	tests := []struct {
+		name     string
+		nums     []int
+		k        int
+		m        Monoid[int]
+		expected []int
+	}{
+		{
+			name:     "sum",
+			nums:     []int{1, 2, 3, 4, 5},
+			k:        3,
+			m:        SumMonoid(),
+			expected: []int{6, 9, 12},
+		},
+		{
+			name:     "min",
+			nums:     []int{4, 2, 12, 3, 8, 7},
+			k:        3,
+			m:        MinMonoid(),
+			expected: []int{2, 2, 3, 3},
+		},
+		{
+			name:     "max",
+			nums:     []int{4, 2, 12, 3, 8, 7},
+			k:        3,
+			m:        MaxMonoid(),
+			expected: []int{12, 12, 12, 8},
+		},
+		{
+			name:     "gcd",
+			nums:     []int{12, 18, 24, 7, 14, 21},
+			k:        2,
+			m:        GCDMonoid(),
+			expected: []int{6, 6, 1, 7, 7},
+		},
+		{
+			name:     "window size 1",
+			nums:     []int{3, -1, 2},
+			k:        1,
+			m:        SumMonoid(),
+			expected: []int{3, -1, 2},
+		},
+		{
+			name:     "k larger than input",
+			nums:     []int{3, 1, 4},
+			k:        10,
+			m:        MaxMonoid(),
+			expected: []int{4},
+		},
+		{
+			name:     "empty array",
+			nums:     []int{},
+			k:        3,
+			m:        SumMonoid(),
+			expected: []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RollingAggregate(tt.nums, tt.k, tt.m)
+			if err != nil {
+				t.Errorf("RollingAggregate(%v, %d) returned error: %v", tt.nums, tt.k, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("RollingAggregate(%v, %d) = %v, want %v", tt.nums, tt.k, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRollingAggregate_MatchesRollingMinMax(t *testing.T) {
+	nums := []int{5, -3, 8, 8, 0, 2, -7, 6, 1, 9, 4, -2}
+
+	for k := 1; k <= len(nums); k++ {
+		wantMins, wantMaxs, _ := RollingMinMax(nums, k)
+
+		mins, _ := RollingAggregate(nums, k, MinMonoid())
+		maxs, _ := RollingAggregate(nums, k, MaxMonoid())
+
+		if !reflect.DeepEqual(mins, wantMins) {
+			t.Errorf("k=%d mins = %v, want %v", k, mins, wantMins)
+		}
+		if !reflect.DeepEqual(maxs, wantMaxs) {
+			t.Errorf("k=%d maxs = %v, want %v", k, maxs, wantMaxs)
+		}
+	}
+}
+
+func TestRollingAggregate_NonCommutative(t *testing.T) {
+	concat := Monoid[string]{Identity: "", Combine: func(a, b string) string { return a + b }}
+
+	got, err := RollingAggregate([]string{"a", "b", "c", "d", "e"}, 3, concat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"abc", "bcd", "cde"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWindowAggregator(t *testing.T) {
+	t.Run("Invalid window size", func(t *testing.T) {
+		if _, err := NewWindowAggregator(0, SumMonoid()); err == nil {
+			t.Error("Expected error for window size 0")
+		}
+		if _, err := RollingAggregate([]int{1}, -1, SumMonoid()); err == nil {
+			t.Error("Expected error for negative window size")
+		}
+	})
+
+	t.Run("Query before window is full", func(t *testing.T) {
+		w, _ := NewWindowAggregator(3, SumMonoid())
+
+		if w.Query() != 0 {
+			t.Errorf("Expected identity 0 on empty window, got %d", w.Query())
+		}
+
+		w.Push(4)
+		w.Push(5)
+		if w.Full() {
+			t.Error("Window should not be full after 2 pushes")
+		}
+		if w.Query() != 9 {
+			t.Errorf("Expected 9, got %d", w.Query())
+		}
+	})
+
+	t.Run("Evicts oldest once full", func(t *testing.T) {
+		w, _ := NewWindowAggregator(2, SumMonoid())
+
+		for _, v := range []int{1, 10, 100, 1000} {
+			w.Push(v)
+		}
+
+		if w.Len() != 2 {
+			t.Errorf("Expected length 2, got %d", w.Len())
+		}
+		if w.Query() != 1100 {
+			t.Errorf("Expected 1100, got %d", w.Query())
+		}
+	})
+}
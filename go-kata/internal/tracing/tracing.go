@@ -0,0 +1,22 @@
+// Package tracing holds the logger that the ds and kata packages send their
+// step-by-step algorithm traces to.
+package tracing
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// logger is nil by default, so tracing costs a single pointer check per step
+// until it is enabled.
+var logger atomic.Pointer[slog.Logger]
+
+// Set routes traces to l at debug level. Pass nil to turn tracing off.
+func Set(l *slog.Logger) {
+	logger.Store(l)
+}
+
+// Logger returns the current trace logger, or nil when tracing is off.
+func Logger() *slog.Logger {
+	return logger.Load()
+}
@@ -1,11 +1,12 @@
 package kata
 
 import (
-	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 	"unicode"
+
+	"go-kata/internal/tracing"
 )
 
 func WordFreq(s string) map[string]int {
@@ -69,11 +70,14 @@ func GroupAnagrams(xs []string) map[string][]string {
 		return string(runes)
 	}
 
+	trace := tracing.Logger()
 	ret := make(map[string][]string)
 
 	for _, s := range xs {
 		key := AnagramSignature(s)
-		fmt.Println("string: ", s, "signature:", key)
+		if trace != nil {
+			trace.Debug("signature", "string", s, "signature", key)
+		}
 		ret[key] = append(ret[key], s)
 	}
 
@@ -161,6 +165,7 @@ func MergeIntervals(intervals []Interval) []Interval {
 		}
 	})
 
+	trace := tracing.Logger()
 	var merged_intervals []Interval
 
 	for idx, new_interval := range intervals {
@@ -176,15 +181,19 @@ func MergeIntervals(intervals []Interval) []Interval {
 
 			new_end := max(last_merged_interval.End, new_interval.End)
 
-			fmt.Printf("Merging intervals: [%d,%d] with [%d,%d] ==> [%d,%d]\n",
-				last_merged_interval.Start, last_merged_interval.End,
-				new_interval.Start, new_interval.End,
-				last_merged_interval.Start, new_end)
+			if trace != nil {
+				trace.Debug("merge",
+					"last", *last_merged_interval,
+					"new", new_interval,
+					"merged", Interval{Start: last_merged_interval.Start, End: new_end})
+			}
 
 			last_merged_interval.End = new_end
 
 		} else {
-			fmt.Printf("Adding new interval: [%d,%d]\n", new_interval.Start, new_interval.End)
+			if trace != nil {
+				trace.Debug("add", "interval", new_interval)
+			}
 			merged_intervals = append(merged_intervals, new_interval)
 		}
 	}
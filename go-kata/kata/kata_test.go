@@ -1,8 +1,11 @@
 package kata
 
 import (
+	"bytes"
+	"log/slog"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -105,6 +108,30 @@ func TestIsBalanced(t *testing.T) {
 // // Harder 11) LRU cache tests
 // //
 
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	SetTracer(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { SetTracer(nil) })
+
+	MergeIntervals([]Interval{{1, 3}, {2, 6}, {8, 10}})
+	GroupAnagrams([]string{"eat"})
+
+	out := buf.String()
+	for _, want := range []string{"msg=merge", "msg=add", "msg=signature", "signature=aet"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected trace to contain %q, got=%q", want, out)
+		}
+	}
+
+	SetTracer(nil)
+	buf.Reset()
+
+	MergeIntervals([]Interval{{1, 3}, {2, 6}})
+	if buf.Len() != 0 {
+		t.Fatalf("expected no trace output when disabled, got=%q", buf.String())
+	}
+}
+
 // func TestLRU(t *testing.T) {
 // 	c, err := NewLRU(2)
 // 	if err != nil {
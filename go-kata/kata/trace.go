@@ -0,0 +1,13 @@
+package kata
+
+import (
+	"log/slog"
+
+	"go-kata/internal/tracing"
+)
+
+// SetTracer sends the katas' debug traces to l, or stops them when l is nil.
+// The same tracer serves package ds.
+func SetTracer(l *slog.Logger) {
+	tracing.Set(l)
+}